@@ -3,7 +3,7 @@ package main
 import (
 	"os"
 
-	"easy8-cli/internal/cli"
+	"github.com/Easy8Com/easy8-cli/internal/cli"
 )
 
 func main() {
@@ -1,3 +1,3 @@
-module easy8-cli
+module github.com/Easy8Com/easy8-cli
 
 go 1.22
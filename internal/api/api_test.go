@@ -1,13 +1,31 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/Easy8Com/easy8-cli/internal/config"
 )
 
 func TestListIssuesBuildsQuery(t *testing.T) {
@@ -243,3 +261,943 @@ func TestLookupEndpoints(t *testing.T) {
 		t.Fatalf("projects: %v %v", projects, err)
 	}
 }
+
+func TestSearchUsersAndMemberships(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/users.json":
+			if r.URL.Query().Get("name") != "alice" {
+				t.Errorf("name = %q", r.URL.Query().Get("name"))
+			}
+			_, _ = w.Write([]byte("{\"users\":[{\"id\":10,\"login\":\"alice\"}],\"total_count\":1,\"offset\":0,\"limit\":100}"))
+		case "/projects/7/memberships.json":
+			if r.URL.Query().Get("offset") == "0" {
+				_, _ = w.Write([]byte("{\"memberships\":[{\"id\":1,\"user\":{\"id\":10,\"name\":\"Alice Doe\"},\"roles\":[{\"id\":3,\"name\":\"Developer\"}]}],\"total_count\":2,\"offset\":0,\"limit\":1}"))
+				return
+			}
+			_, _ = w.Write([]byte("{\"memberships\":[{\"id\":2,\"group\":{\"id\":90,\"name\":\"Staff\"}}],\"total_count\":2,\"offset\":1,\"limit\":1}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(config.Config{BaseURL: server.URL, APIKey: "test"})
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	users, err := client.SearchUsers(context.Background(), "alice")
+	if err != nil || len(users) != 1 || users[0].ID != 10 {
+		t.Fatalf("users = %+v err=%v", users, err)
+	}
+	memberships, err := client.ListProjectMemberships(context.Background(), 7)
+	if err != nil || len(memberships) != 2 {
+		t.Fatalf("memberships = %+v err=%v", memberships, err)
+	}
+	if memberships[0].User == nil || memberships[0].User.Name != "Alice Doe" || memberships[1].Group == nil {
+		t.Fatalf("unexpected memberships: %+v", memberships)
+	}
+}
+
+func newOAuthServer(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	var challenge string
+	refreshes := 0
+	handler := http.NewServeMux()
+	handler.HandleFunc("/oauth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "cli" {
+			t.Errorf("authorize query = %s", r.URL.RawQuery)
+		}
+		challenge = query.Get("code_challenge")
+		target := query.Get("redirect_uri") + "?code=auth-code&state=" + url.QueryEscape(query.Get("state"))
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+	handler.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			if r.PostForm.Get("code") != "auth-code" || pkceChallenge(r.PostForm.Get("code_verifier")) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte("{\"error\":\"invalid_grant\",\"error_description\":\"bad verifier\"}"))
+				return
+			}
+			_, _ = w.Write([]byte("{\"access_token\":\"access-1\",\"refresh_token\":\"refresh-1\",\"expires_in\":3600,\"token_type\":\"Bearer\"}"))
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte("{\"error\":\"invalid_grant\"}"))
+				return
+			}
+			refreshes++
+			_, _ = w.Write([]byte("{\"access_token\":\"access-2\",\"expires_in\":3600}"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	handler.HandleFunc("/issues.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issues\":[{\"id\":1,\"subject\":\"Test\"}],\"total_count\":1,\"offset\":0,\"limit\":25}"))
+	})
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server, &refreshes
+}
+
+func TestOAuthLoginUsesPKCE(t *testing.T) {
+	server, _ := newOAuthServer(t)
+	store := FileTokenStore{Path: filepath.Join(t.TempDir(), "token.json")}
+	oauth := NewOAuth(server.URL, OAuthConfig{ClientID: "cli"}, store)
+
+	token, err := oauth.Login(context.Background(), func(authURL string) error {
+		resp, err := http.Get(authURL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	})
+	if err != nil {
+		t.Fatalf("Login error: %v", err)
+	}
+	if token.AccessToken != "access-1" || token.RefreshToken != "refresh-1" || token.Expiry.IsZero() {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+	stored, err := store.Load()
+	if err != nil || stored.AccessToken != "access-1" {
+		t.Fatalf("stored token: %+v %v", stored, err)
+	}
+}
+
+func TestOAuthRefreshesOn401(t *testing.T) {
+	server, refreshes := newOAuthServer(t)
+	store := FileTokenStore{Path: filepath.Join(t.TempDir(), "token.json")}
+	if err := store.Save(Token{AccessToken: "access-1", RefreshToken: "refresh-1"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	client := &Client{BaseURL: server.URL, HTTP: server.Client(), OAuth: NewOAuth(server.URL, OAuthConfig{ClientID: "cli"}, store)}
+	resp, err := client.ListIssues(context.Background(), IssueListParams{})
+	if err != nil {
+		t.Fatalf("ListIssues error: %v", err)
+	}
+	if len(resp.Issues) != 1 || *refreshes != 1 {
+		t.Fatalf("issues=%d refreshes=%d", len(resp.Issues), *refreshes)
+	}
+	stored, err := store.Load()
+	if err != nil || stored.AccessToken != "access-2" || stored.RefreshToken != "refresh-1" {
+		t.Fatalf("stored token: %+v %v", stored, err)
+	}
+}
+
+func TestOAuthNotLoggedIn(t *testing.T) {
+	store := FileTokenStore{Path: filepath.Join(t.TempDir(), "token.json")}
+	client := &Client{BaseURL: "https://example.com", HTTP: http.DefaultClient, OAuth: NewOAuth("https://example.com", OAuthConfig{ClientID: "cli"}, store)}
+	_, err := client.ListIssues(context.Background(), IssueListParams{})
+	if !errors.Is(err, ErrNotLoggedIn) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestOAuthNeedsTokenLocation(t *testing.T) {
+	t.Setenv("HOME", "")
+	_, err := NewClient(config.Config{BaseURL: "https://example.com", AuthMode: config.AuthModeOAuth2})
+	if err == nil || !strings.Contains(err.Error(), "locating the OAuth2 token") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSwitchUserHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Redmine-Switch-User") != "alice" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issue\":{\"id\":10,\"subject\":\"New\"}}"))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, APIKey: "key", HTTP: server.Client(), SwitchUser: "alice"}
+	if _, err := client.CreateIssue(context.Background(), IssueInput{}); err != nil {
+		t.Fatalf("CreateIssue error: %v", err)
+	}
+
+	client.SwitchUser = "locked"
+	_, err := client.CreateIssue(context.Background(), IssueInput{})
+	if err == nil || !strings.Contains(err.Error(), "rejected impersonation of \"locked\"") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("expected wrapped APIError: %v", err)
+	}
+}
+
+func noSleep(delays *[]time.Duration) func(context.Context, time.Duration) error {
+	return func(ctx context.Context, delay time.Duration) error {
+		*delays = append(*delays, delay)
+		return nil
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"trackers\":[{\"id\":1,\"name\":\"Task\"}]}"))
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &Client{BaseURL: server.URL, APIKey: "key", HTTP: server.Client(), Retry: RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Minute}}
+	client.sleep = noSleep(&delays)
+	trackers, err := client.ListTrackers(context.Background())
+	if err != nil || len(trackers) != 1 {
+		t.Fatalf("trackers: %v %v", trackers, err)
+	}
+	if calls != 3 || len(delays) != 2 || delays[0] != 2*time.Second {
+		t.Fatalf("calls=%d delays=%v", calls, delays)
+	}
+}
+
+func TestRetryGivesUpWhenRetryAfterExceedsMaxDelay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &Client{BaseURL: server.URL, APIKey: "key", HTTP: server.Client(), Retry: RetryPolicy{Attempts: 3, MaxDelay: time.Second}}
+	client.sleep = noSleep(&delays)
+	_, err := client.ListTrackers(context.Background())
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || calls != 1 {
+		t.Fatalf("calls=%d err=%v", calls, err)
+	}
+}
+
+func TestRetryPostRequiresIdempotencyKey(t *testing.T) {
+	calls := 0
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issue\":{\"id\":10,\"subject\":\"New\"}}"))
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &Client{BaseURL: server.URL, APIKey: "key", HTTP: server.Client(), Retry: RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond}}
+	client.sleep = noSleep(&delays)
+	if _, err := client.CreateIssue(context.Background(), IssueInput{}); err == nil || calls != 1 {
+		t.Fatalf("calls=%d err=%v", calls, err)
+	}
+
+	ctx := WithIdempotencyKey(context.Background(), "create-1")
+	resp, err := client.CreateIssue(ctx, IssueInput{})
+	if err != nil || resp.Issue.ID != 10 {
+		t.Fatalf("CreateIssue: %+v %v", resp, err)
+	}
+	if calls != 3 || keys[1] != "create-1" || keys[2] != "create-1" {
+		t.Fatalf("calls=%d keys=%v", calls, keys)
+	}
+}
+
+func TestWithConfigKeepsOtherOptions(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("User-Agent"), r.Header.Get("X-Redmine-API-Key"), r.Header.Get("X-Redmine-Switch-User"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issue_statuses\":[]}"))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	logged := 0
+	opts := []Option{
+		OptionFunc(func(c *Client) error {
+			c.UserAgent, c.SwitchUser = "agent", "alice"
+			c.Use(func(next http.RoundTripper) http.RoundTripper {
+				return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					logged++
+					return next.RoundTrip(req)
+				})
+			})
+			return nil
+		}),
+		WithConfig(config.Config{BaseURL: "https://ignored.example", APIKey: "key", RPS: 50}),
+	}
+	for _, opt := range opts {
+		if err := opt.Apply(client); err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+	}
+	if _, err := client.ListIssueStatuses(context.Background()); err != nil {
+		t.Fatalf("ListIssueStatuses: %v", err)
+	}
+	if client.BaseURL != server.URL || client.Limiter == nil || logged != 1 || strings.Join(seen, ",") != "agent,key,alice" {
+		t.Fatalf("base=%s limiter=%v logged=%d seen=%v", client.BaseURL, client.Limiter, logged, seen)
+	}
+}
+
+func TestRetrySkipsIssueUpdates(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &Client{BaseURL: server.URL, APIKey: "key", HTTP: server.Client(), Retry: RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond}}
+	client.sleep = noSleep(&delays)
+	notes := "Deployed"
+	if _, err := client.UpdateIssue(context.Background(), 101, IssueInput{Notes: &notes}); err == nil || calls != 1 {
+		t.Fatalf("calls=%d err=%v", calls, err)
+	}
+}
+
+func TestRetryConnectionReset(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack: %v", err)
+				return
+			}
+			_ = conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issue_statuses\":[{\"id\":2,\"name\":\"New\"}]}"))
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &Client{BaseURL: server.URL, APIKey: "key", HTTP: server.Client(), Retry: RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond}}
+	client.sleep = noSleep(&delays)
+	statuses, err := client.ListIssueStatuses(context.Background())
+	if err != nil || len(statuses) != 1 || calls != 2 {
+		t.Fatalf("calls=%d statuses=%v err=%v", calls, statuses, err)
+	}
+}
+
+func TestRetryBackoffAndRetryAfterParsing(t *testing.T) {
+	policy := RetryPolicy{Attempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	if got := policy.backoff(1); got != 100*time.Millisecond {
+		t.Fatalf("backoff(1) = %v", got)
+	}
+	if got := policy.backoff(2); got != 200*time.Millisecond {
+		t.Fatalf("backoff(2) = %v", got)
+	}
+	if got := policy.backoff(4); got != 300*time.Millisecond {
+		t.Fatalf("backoff(4) = %v", got)
+	}
+	uncapped := RetryPolicy{Attempts: 5, BaseDelay: time.Second}
+	if got := uncapped.backoff(4); got != 8*time.Second {
+		t.Fatalf("uncapped backoff(4) = %v", got)
+	}
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		if got := policy.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("jittered backoff = %v", got)
+		}
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if delay, ok := parseRetryAfter("Mon, 01 Jan 2024 12:00:30 GMT", now); !ok || delay != 30*time.Second {
+		t.Fatalf("http date: %v %v", delay, ok)
+	}
+	if _, ok := parseRetryAfter("soon", now); ok {
+		t.Fatalf("expected invalid Retry-After")
+	}
+}
+
+func TestConcurrencyLimitBoundsInflight(t *testing.T) {
+	var mu sync.Mutex
+	inflight, peak := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inflight++
+		if inflight > peak {
+			peak = inflight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"trackers\":[]}"))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, APIKey: "key", HTTP: server.Client(), Inflight: NewSemaphore(2)}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.ListTrackers(context.Background()); err != nil {
+				t.Errorf("ListTrackers: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak > 2 {
+		t.Fatalf("peak inflight = %d", peak)
+	}
+}
+
+func TestRateLimiterPacesRequests(t *testing.T) {
+	limiter := NewRateLimiter(100, 1)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Errorf("Wait: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Fatalf("6 tokens at 100 rps took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewRateLimiter(0.001, 1).Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
+
+func TestAPIErrorParsesValidationErrors(t *testing.T) {
+	err := newAPIError(422, []byte("{\"errors\":[\"Subject cannot be blank\",\"Tracker is invalid\"]}"), "https://example.com/issues.json")
+	if err.Kind() != ErrorKindValidation {
+		t.Fatalf("kind = %s", err.Kind())
+	}
+	if len(err.Errors) != 2 || err.Errors[0] != "Subject cannot be blank" {
+		t.Fatalf("errors = %v", err.Errors)
+	}
+	if err.Error() != "api error 422: Subject cannot be blank; Tracker is invalid" {
+		t.Fatalf("message = %s", err.Error())
+	}
+
+	fields := newAPIError(422, []byte("{\"errors\":{\"subject\":[\"cannot be blank\"],\"due_date\":[\"is invalid\"]}}"), "")
+	if len(fields.Errors) != 2 || fields.Errors[0] != "due_date is invalid" || fields.Errors[1] != "subject cannot be blank" {
+		t.Fatalf("field errors = %v", fields.Errors)
+	}
+
+	single := newAPIError(409, []byte("{\"error\":\"stale object\"}"), "")
+	if single.Kind() != ErrorKindConflict || len(single.Errors) != 1 {
+		t.Fatalf("single = %+v", single)
+	}
+	if plain := newAPIError(502, []byte("<html>bad gateway</html>"), ""); plain.Errors != nil || plain.Kind() != ErrorKindServer {
+		t.Fatalf("plain = %+v", plain)
+	}
+}
+
+func TestAPIErrorKinds(t *testing.T) {
+	cases := map[int]ErrorKind{
+		401: ErrorKindAuth,
+		403: ErrorKindForbidden,
+		404: ErrorKindNotFound,
+		400: ErrorKindOther,
+		409: ErrorKindConflict,
+		429: ErrorKindRateLimit,
+		500: ErrorKindServer,
+		503: ErrorKindServer,
+	}
+	for status, want := range cases {
+		if got := (APIError{StatusCode: status}).Kind(); got != want {
+			t.Fatalf("status %d kind = %s, want %s", status, got, want)
+		}
+	}
+}
+
+func TestLoggingTransportRedactsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issue\":{\"id\":10,\"subject\":\"New\"}}"))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := &Client{BaseURL: server.URL, APIKey: "secret-key", HTTP: &http.Client{Transport: &LoggingTransport{Out: &out, Trace: true}}}
+	subject := "New"
+	resp, err := client.CreateIssue(context.Background(), IssueInput{Subject: &subject})
+	if err != nil || resp.Issue.ID != 10 {
+		t.Fatalf("CreateIssue: %+v %v", resp, err)
+	}
+
+	logged := out.String()
+	if strings.Contains(logged, "secret-key") {
+		t.Fatalf("API key leaked: %s", logged)
+	}
+	for _, want := range []string{"> POST " + server.URL + "/issues.json", "> X-Redmine-Api-Key: [REDACTED]", ">     \"subject\": \"New\"", "< 200 OK (", "<     \"id\": 10,"} {
+		if !strings.Contains(logged, want) {
+			t.Fatalf("missing %q in log:\n%s", want, logged)
+		}
+	}
+
+	out.Reset()
+	client.HTTP.Transport = &LoggingTransport{Out: &out}
+	if _, err := client.ListTrackers(context.Background()); err != nil {
+		t.Fatalf("ListTrackers: %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 2 {
+		t.Fatalf("verbose log should have two lines:\n%s", out.String())
+	}
+}
+
+func TestHARRecorderWritesEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"trackers\":[{\"id\":1,\"name\":\"Task\"}]}"))
+	}))
+	defer server.Close()
+
+	recorder := &HARRecorder{}
+	client := &Client{BaseURL: server.URL, APIKey: "secret-key", HTTP: &http.Client{Transport: recorder}}
+	trackers, err := client.ListTrackers(context.Background())
+	if err != nil || len(trackers) != 1 {
+		t.Fatalf("trackers: %v %v", trackers, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.har")
+	if err := recorder.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Fatalf("API key leaked: %s", data)
+	}
+	var har harLog
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatalf("unexpected har: %+v", har.Log)
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.Method != "GET" || entry.Response.Status != 200 || !strings.Contains(entry.Response.Content.Text, "Task") {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+}
+
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "easy8-cli test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return certPath, keyPath
+}
+
+func TestNewClientTLSSettings(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"trackers\":[{\"id\":1,\"name\":\"Task\"}]}"))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600); err != nil {
+		t.Fatalf("write ca: %v", err)
+	}
+	certPath, keyPath := writeTestCertificate(t, dir)
+
+	client, err := NewClient(config.Config{BaseURL: server.URL, APIKey: "key", CAFile: caPath, ClientCert: certPath, ClientKey: keyPath, RequestTimeout: 5})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if client.HTTP.Timeout != 5*time.Second {
+		t.Fatalf("timeout = %v", client.HTTP.Timeout)
+	}
+	if trackers, err := client.ListTrackers(context.Background()); err != nil || len(trackers) != 1 {
+		t.Fatalf("trackers: %v %v", trackers, err)
+	}
+
+	untrusted, err := NewClient(config.Config{BaseURL: server.URL, APIKey: "key", ClientCert: certPath, ClientKey: keyPath})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if _, err := untrusted.ListTrackers(context.Background()); err == nil {
+		t.Fatalf("expected certificate verification error")
+	}
+
+	skipVerify := true
+	insecure, err := NewClient(config.Config{BaseURL: server.URL, APIKey: "key", InsecureSkipVerify: &skipVerify, ClientCert: certPath, ClientKey: keyPath})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if _, err := insecure.ListTrackers(context.Background()); err != nil {
+		t.Fatalf("insecure ListTrackers: %v", err)
+	}
+}
+
+func TestNewClientProxyAndInvalidSettings(t *testing.T) {
+	client, err := NewClient(config.Config{BaseURL: "https://example.com", ProxyURL: "http://proxy.local:3128"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	transport := client.HTTP.Transport.(*http.Transport)
+	req, _ := http.NewRequest("GET", "https://example.com/issues.json", nil)
+	proxy, err := transport.Proxy(req)
+	if err != nil || proxy == nil || proxy.Host != "proxy.local:3128" {
+		t.Fatalf("proxy = %v %v", proxy, err)
+	}
+
+	invalid := []config.Config{
+		{ProxyURL: "::not a url"},
+		{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		{ClientCert: "client.pem"},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewClient(cfg); err == nil {
+			t.Fatalf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func fakePages(total, serverLimit int, calls *int, mu *sync.Mutex) PageFetcher[int] {
+	return func(ctx context.Context, offset, limit int) (Page[int], error) {
+		mu.Lock()
+		*calls++
+		mu.Unlock()
+		if limit > serverLimit {
+			limit = serverLimit
+		}
+		var items []int
+		for i := offset; i < offset+limit && i < total; i++ {
+			items = append(items, i)
+		}
+		return Page[int]{Items: items, TotalCount: total, Offset: offset, Limit: limit}, nil
+	}
+}
+
+func TestPaginateServerLimitAndEarlyStop(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	items, err := CollectAll(context.Background(), fakePages(95, 30, &calls, &mu), PageOptions{PageSize: 100})
+	if err != nil || len(items) != 95 || items[94] != 94 || calls != 4 {
+		t.Fatalf("items = %d calls = %d err=%v", len(items), calls, err)
+	}
+
+	calls = 0
+	items, err = CollectAll(context.Background(), fakePages(95, 30, &calls, &mu), PageOptions{PageSize: 20, Offset: 10, Max: 25})
+	if err != nil || len(items) != 25 || items[0] != 10 || calls != 2 {
+		t.Fatalf("items = %v calls = %d err=%v", items, calls, err)
+	}
+
+	calls = 0
+	seen := 0
+	total, err := Paginate(context.Background(), fakePages(95, 30, &calls, &mu), PageOptions{}, func(item int) error {
+		seen++
+		if item == 40 {
+			return ErrStopPaging
+		}
+		return nil
+	})
+	if err != nil || total != 95 || seen != 41 || calls != 2 {
+		t.Fatalf("total = %d seen = %d calls = %d err=%v", total, seen, calls, err)
+	}
+}
+
+func TestPaginateWithoutTotalAndBogusTotal(t *testing.T) {
+	noTotal := func(ctx context.Context, offset, limit int) (Page[int], error) {
+		if offset >= 5 {
+			return Page[int]{}, nil
+		}
+		return Page[int]{Items: []int{offset, offset + 1, offset + 2}[:min(3, 5-offset)], Limit: 3}, nil
+	}
+	items, err := CollectAll(context.Background(), noTotal, PageOptions{PageSize: 10})
+	if err != nil || len(items) != 5 {
+		t.Fatalf("items = %v err=%v", items, err)
+	}
+
+	calls := 0
+	bogus := func(ctx context.Context, offset, limit int) (Page[int], error) {
+		calls++
+		if offset > 0 {
+			return Page[int]{TotalCount: 1000, Limit: limit}, nil
+		}
+		return Page[int]{Items: []int{1, 2}, TotalCount: 1000, Limit: 2}, nil
+	}
+	items, err = CollectAll(context.Background(), bogus, PageOptions{})
+	if err != nil || len(items) != 2 || calls != 2 {
+		t.Fatalf("items = %v calls = %d err=%v", items, calls, err)
+	}
+}
+
+func TestPaginatePrefetchKeepsOrder(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	var inflight, peak int32
+	pages := fakePages(250, 25, &calls, &mu)
+	slow := func(ctx context.Context, offset, limit int) (Page[int], error) {
+		current := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(250-offset) * time.Microsecond * 20)
+		return pages(ctx, offset, limit)
+	}
+
+	var items []int
+	total, err := Paginate(context.Background(), slow, PageOptions{Prefetch: 4}, func(item int) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil || total != 250 || len(items) != 250 || calls != 10 {
+		t.Fatalf("total = %d items = %d calls = %d err=%v", total, len(items), calls, err)
+	}
+	for i, item := range items {
+		if item != i {
+			t.Fatalf("out of order at %d: %d", i, item)
+		}
+	}
+	if peak < 2 || peak > 4 {
+		t.Fatalf("peak in flight = %d", peak)
+	}
+}
+
+func TestIssuePagesKeepsFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("status_id") != "2" || query.Get("set_filter") != "1" || query.Get("limit") != "2" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		offset := query.Get("offset")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issues\":[{\"id\":1" + offset + "},{\"id\":2" + offset + "}],\"total_count\":4,\"offset\":" + offset + ",\"limit\":2}"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(config.Config{BaseURL: server.URL, APIKey: "test"})
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	issues, err := CollectAll(context.Background(), client.IssuePages(IssueListParams{StatusID: 2, Limit: 7}), PageOptions{PageSize: 2})
+	if err != nil || len(issues) != 4 || issues[2].ID != 12 {
+		t.Fatalf("issues = %+v err=%v", issues, err)
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "{\"issue\":{\"subject\":\"Retry me\"}}" {
+			t.Errorf("attempt %d body = %s", calls, body)
+		}
+		if r.Header.Get("User-Agent") != DefaultUserAgent || r.Header.Get("X-Audit") != "yes" || r.Header.Get("X-Redmine-API-Key") != "key" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		if calls < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issue\":{\"id\":5,\"subject\":\"Retry me\"}}"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(config.Config{BaseURL: server.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	var delays []time.Duration
+	client.sleep = noSleep(&delays)
+
+	var outer, inner []string
+	client.Middleware = append([]Middleware{func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			outer = append(outer, req.Method)
+			return next.RoundTrip(req)
+		})
+	}}, client.DefaultMiddleware()...)
+	client.Use(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Audit", "yes")
+			resp, err := next.RoundTrip(req)
+			if err == nil {
+				inner = append(inner, resp.Status)
+			}
+			return resp, err
+		})
+	})
+
+	subject := "Retry me"
+	ctx := WithIdempotencyKey(context.Background(), "k1")
+	resp, err := client.CreateIssue(ctx, IssueInput{Subject: &subject})
+	if err != nil || resp.Issue.ID != 5 {
+		t.Fatalf("resp = %+v err=%v", resp, err)
+	}
+	if len(outer) != 1 || len(inner) != 2 || inner[0] != "503 Service Unavailable" {
+		t.Fatalf("outer = %v inner = %v", outer, inner)
+	}
+}
+
+func TestBodyReadingMiddlewareKeepsRequest(t *testing.T) {
+	recorder, err := NewRecorder(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	middleware := map[string]Middleware{
+		"logging":  Logging(io.Discard, true),
+		"har":      (&HARRecorder{}).Middleware(),
+		"cassette": recorder.Middleware(),
+	}
+	for name, m := range middleware {
+		var sent string
+		handler := m(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			sent = string(body)
+			return &http.Response{StatusCode: 200, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("{}")), Request: req}, nil
+		}))
+
+		req, _ := http.NewRequest(http.MethodPost, "https://example.com/issues.json", strings.NewReader("payload"))
+		body := req.Body
+		if _, err := handler.RoundTrip(req); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if sent != "payload" || req.Body != body {
+			t.Fatalf("%s: sent %q, request body replaced: %v", name, sent, req.Body != body)
+		}
+
+		req, _ = http.NewRequest(http.MethodPost, "https://example.com/issues.json", nil)
+		req.Body = io.NopCloser(strings.NewReader("payload"))
+		body = req.Body
+		if _, err := handler.RoundTrip(req); err != nil {
+			t.Fatalf("%s without GetBody: %v", name, err)
+		}
+		if sent != "payload" || req.Body != body {
+			t.Fatalf("%s without GetBody: sent %q, request body replaced: %v", name, sent, req.Body != body)
+		}
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write([]byte("{\"issues\":[{\"id\":" + r.URL.Query().Get("limit") + ",\"subject\":\"Recorded\"}],\"total_count\":1,\"offset\":0,\"limit\":5}"))
+	}))
+	dir := filepath.Join(t.TempDir(), "cassette")
+
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatalf("recorder: %v", err)
+	}
+	client, err := NewClient(config.Config{BaseURL: server.URL, APIKey: "secret-key"})
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	client.Use(recorder.Middleware())
+	if _, err := client.ListIssues(context.Background(), IssueListParams{Limit: 5, StatusID: 2}); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	server.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 || filepath.Base(files[0]) != "0001-get-issues.json" {
+		t.Fatalf("files = %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if strings.Contains(string(data), "secret") || !strings.Contains(string(data), "/issues.json?limit=5&set_filter=1&status_id=2") {
+		t.Fatalf("unexpected recording: %s", data)
+	}
+	if _, err := NewRecorder(dir); err == nil {
+		t.Fatalf("expected an error for a non-empty record directory")
+	}
+
+	replayer, err := LoadReplayer(dir)
+	if err != nil {
+		t.Fatalf("replayer: %v", err)
+	}
+	offline := &Client{BaseURL: "http://replay.invalid", APIKey: "other", HTTP: http.DefaultClient}
+	offline.Use(replayer.Middleware())
+	for i := 0; i < 2; i++ {
+		resp, err := offline.ListIssues(context.Background(), IssueListParams{Limit: 5, StatusID: 2})
+		if err != nil || len(resp.Issues) != 1 || resp.Issues[0].Subject != "Recorded" {
+			t.Fatalf("replay %d: resp = %+v err=%v", i, resp, err)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "http://other.host/issues.json?status_id=2&set_filter=1&limit=5", nil)
+	if resp, err := replayer.RoundTrip(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("query order should not matter: %v", err)
+	}
+
+	_, err = offline.ListIssues(context.Background(), IssueListParams{Limit: 10})
+	if !errors.Is(err, ErrUnmatchedRequest) || !strings.Contains(err.Error(), "GET /issues.json?limit=10 (recorded for this path: GET /issues.json?limit=5") {
+		t.Fatalf("err = %v", err)
+	}
+}
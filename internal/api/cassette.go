@@ -0,0 +1,280 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnmatchedRequest is returned by a Replayer for a request that has no
+// recorded interaction.
+var ErrUnmatchedRequest = errors.New("no recorded interaction")
+
+var unrecordedHeaders = map[string]bool{
+	"Content-Length": true,
+	"Date":           true,
+	"User-Agent":     true,
+}
+
+type cassetteInteraction struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+type cassetteRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type cassetteResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Recorder writes every request/response pair passing through its middleware
+// to Dir, one numbered JSON file each. Credentials are dropped, query
+// parameters sorted and JSON bodies compacted so recordings diff cleanly.
+type Recorder struct {
+	Dir string
+
+	mu    sync.Mutex
+	count int
+}
+
+// NewRecorder creates dir. It refuses a directory that already holds a
+// recording so two runs are never mixed.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("record directory %s already contains a recording", dir)
+	}
+	return &Recorder{Dir: dir}, nil
+}
+
+func (r *Recorder) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req, requestBody, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			responseBody, err := readResponseBody(resp)
+			if err != nil {
+				return nil, err
+			}
+			interaction := cassetteInteraction{
+				Request: cassetteRequest{
+					Method:  req.Method,
+					URL:     normalizeURL(req),
+					Headers: cassetteHeaders(req.Header),
+					Body:    normalizeBody(requestBody),
+				},
+				Response: cassetteResponse{
+					Status:  resp.StatusCode,
+					Headers: cassetteHeaders(resp.Header),
+					Body:    string(responseBody),
+				},
+			}
+			if err := r.write(interaction); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		})
+	}
+}
+
+func (r *Recorder) write(interaction cassetteInteraction) error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(interaction); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.count++
+	name := fmt.Sprintf("%04d-%s-%s.json", r.count, strings.ToLower(interaction.Request.Method), pathSlug(interaction.Request.URL))
+	return os.WriteFile(filepath.Join(r.Dir, name), data.Bytes(), 0o600)
+}
+
+// Replayer serves a recording back without touching the network. Requests
+// match on method, path, query parameters in any order and the JSON body.
+// Identical requests are answered in recorded order; once those run out the
+// last one is repeated.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []cassetteInteraction
+	used         []bool
+}
+
+func LoadReplayer(dir string) (*Replayer, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no recording found in %s", dir)
+	}
+	sort.Strings(paths)
+	replayer := &Replayer{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var interaction cassetteInteraction
+		if err := json.Unmarshal(data, &interaction); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		replayer.interactions = append(replayer.interactions, interaction)
+	}
+	replayer.used = make([]bool, len(replayer.interactions))
+	return replayer, nil
+}
+
+func (r *Replayer) Middleware() Middleware {
+	return func(http.RoundTripper) http.RoundTripper {
+		return r
+	}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, requestBody, err := readRequestBody(req)
+	if req.Body != nil {
+		// Nothing is sent, but a RoundTripper closes the body it is given.
+		_ = req.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	url := normalizeURL(req)
+	body := normalizeBody(requestBody)
+
+	r.mu.Lock()
+	match := -1
+	for i, interaction := range r.interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.URL != url || interaction.Request.Body != body {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match >= 0 {
+		r.used[match] = true
+	}
+	r.mu.Unlock()
+
+	if match < 0 {
+		return nil, r.unmatched(req.Method, url)
+	}
+	recorded := r.interactions[match].Response
+	header := http.Header{}
+	for name, value := range recorded.Headers {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Replayer) unmatched(method, url string) error {
+	path, _, _ := strings.Cut(url, "?")
+	var closest []string
+	for _, interaction := range r.interactions {
+		recordedPath, _, _ := strings.Cut(interaction.Request.URL, "?")
+		if recordedPath == path {
+			closest = append(closest, interaction.Request.Method+" "+interaction.Request.URL)
+		}
+	}
+	if len(closest) == 0 {
+		return fmt.Errorf("replay: %w for %s %s", ErrUnmatchedRequest, method, url)
+	}
+	return fmt.Errorf("replay: %w for %s %s (recorded for this path: %s)", ErrUnmatchedRequest, method, url, strings.Join(closest, ", "))
+}
+
+// normalizeURL keeps the path and the query with parameters sorted by name,
+// so the base URL and parameter order do not affect matching.
+func normalizeURL(req *http.Request) string {
+	normalized := req.URL.EscapedPath()
+	if encoded := req.URL.Query().Encode(); encoded != "" {
+		normalized += "?" + encoded
+	}
+	return normalized
+}
+
+func normalizeBody(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+	compact, err := json.Marshal(value)
+	if err != nil {
+		return string(body)
+	}
+	return string(compact)
+}
+
+func cassetteHeaders(header http.Header) map[string]string {
+	result := map[string]string{}
+	for name, values := range header {
+		name = http.CanonicalHeaderKey(name)
+		if sensitiveHeaders[name] || unrecordedHeaders[name] || len(values) == 0 {
+			continue
+		}
+		result[name] = strings.Join(values, ", ")
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func pathSlug(url string) string {
+	path, _, _ := strings.Cut(url, "?")
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".json")
+	slug := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, path)
+	if slug == "" {
+		return "root"
+	}
+	return slug
+}
@@ -11,41 +11,115 @@ import (
 	"strings"
 	"time"
 
-	"easy8-cli/internal/config"
+	"github.com/Easy8Com/easy8-cli/internal/config"
 )
 
 type Client struct {
 	BaseURL string
 	APIKey  string
 	HTTP    *http.Client
+	OAuth   *OAuth
+
+	SwitchUser string
+	Retry      RetryPolicy
+	Limiter    *RateLimiter
+	Inflight   *Semaphore
+	UserAgent  string
+
+	// Middleware is the chain every request passes through, outermost first.
+	// Nil means DefaultMiddleware. See Use.
+	Middleware []Middleware
+
+	sleep func(ctx context.Context, delay time.Duration) error
+}
+
+// Option configures a Client. pkg/easy8 exports it as easy8.Option; its
+// fields are unexported so that options can only be made in this module.
+type Option struct {
+	apply func(*Client) error
+}
+
+func OptionFunc(apply func(*Client) error) Option {
+	return Option{apply: apply}
 }
 
-func NewClient(cfg config.Config) *Client {
-	base := strings.TrimRight(cfg.BaseURL, "/")
-	return &Client{
-		BaseURL: base,
-		APIKey:  cfg.APIKey,
-		HTTP: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// Apply configures c; the zero Option does nothing.
+func (o Option) Apply(c *Client) error {
+	if o.apply == nil {
+		return nil
 	}
+	return o.apply(c)
 }
 
-type APIError struct {
-	StatusCode int
-	Body       string
-	URL        string
+func NewClient(cfg config.Config) (*Client, error) {
+	client := &Client{BaseURL: strings.TrimRight(cfg.BaseURL, "/")}
+	if err := WithConfig(cfg).Apply(client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// WithConfig sets the transport, timeout, credentials, retry policy and
+// limits of a profile. The base URL, user agent, acting user and middleware
+// are left alone, so it combines with the options that set those.
+func WithConfig(cfg config.Config) Option {
+	return OptionFunc(func(c *Client) error {
+		transport, err := newTransport(cfg)
+		if err != nil {
+			return err
+		}
+		timeout := 30 * time.Second
+		if cfg.RequestTimeout > 0 {
+			timeout = time.Duration(cfg.RequestTimeout) * time.Second
+		}
+		c.APIKey = cfg.APIKey
+		c.HTTP = &http.Client{Transport: transport, Timeout: timeout}
+		c.Retry = retryPolicyFromConfig(cfg.Retry)
+		c.Limiter, c.Inflight, c.OAuth = nil, nil, nil
+		if cfg.RPS > 0 {
+			c.Limiter = NewRateLimiter(cfg.RPS, 0)
+		}
+		if cfg.Concurrency > 0 {
+			c.Inflight = NewSemaphore(cfg.Concurrency)
+		}
+		if cfg.AuthMode == config.AuthModeOAuth2 {
+			path, err := config.TokenPath(cfg.Profile)
+			if err != nil {
+				return fmt.Errorf("locating the OAuth2 token: %w", err)
+			}
+			c.OAuth = NewOAuth(c.BaseURL, OAuthConfig{
+				ClientID:     cfg.OAuth.ClientID,
+				ClientSecret: cfg.OAuth.ClientSecret,
+				AuthorizeURL: cfg.OAuth.AuthorizeURL,
+				TokenURL:     cfg.OAuth.TokenURL,
+				Scopes:       cfg.OAuth.Scopes,
+				RedirectPort: cfg.OAuth.RedirectPort,
+			}, FileTokenStore{Path: path})
+			c.OAuth.HTTP = &http.Client{Transport: transport, Timeout: timeout}
+		}
+		return nil
+	})
 }
 
-func (err APIError) Error() string {
-	if err.Body == "" {
-		return fmt.Sprintf("api error %d", err.StatusCode)
+func retryPolicyFromConfig(cfg config.Retry) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if cfg.Attempts > 0 {
+		policy.Attempts = cfg.Attempts
 	}
-	return fmt.Sprintf("api error %d: %s", err.StatusCode, err.Body)
+	if cfg.BaseDelayMS > 0 {
+		policy.BaseDelay = time.Duration(cfg.BaseDelayMS) * time.Millisecond
+	}
+	if cfg.MaxDelayMS > 0 {
+		policy.MaxDelay = time.Duration(cfg.MaxDelayMS) * time.Millisecond
+	}
+	if cfg.Jitter != nil {
+		policy.Jitter = *cfg.Jitter
+	}
+	return policy
 }
 
 func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, body any, out any) error {
-	if c.APIKey == "" {
+	if c.APIKey == "" && c.OAuth == nil {
 		return fmt.Errorf("missing API key")
 	}
 
@@ -58,26 +132,20 @@ func (c *Client) doJSON(ctx context.Context, method, path string, query url.Valu
 		}
 	}
 
-	var bodyReader io.Reader
+	var payload []byte
 	if body != nil {
-		payload, err := json.Marshal(body)
+		encoded, err := json.Marshal(body)
 		if err != nil {
 			return err
 		}
-		bodyReader = bytes.NewReader(payload)
+		payload = encoded
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, urlValue, bodyReader)
+	req, err := c.newRequest(ctx, method, urlValue, payload)
 	if err != nil {
 		return err
 	}
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("X-Redmine-API-Key", c.APIKey)
-
-	resp, err := c.HTTP.Do(req)
+	resp, err := c.handler().RoundTrip(req)
 	if err != nil {
 		return err
 	}
@@ -87,8 +155,11 @@ func (c *Client) doJSON(ctx context.Context, method, path string, query url.Valu
 	if err != nil {
 		return err
 	}
+	if resp.StatusCode == http.StatusPreconditionFailed && c.SwitchUser != "" {
+		return fmt.Errorf("server rejected impersonation of %q (X-Redmine-Switch-User needs an admin key and an active user): %w", c.SwitchUser, newAPIError(resp.StatusCode, respBody, urlValue))
+	}
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		return APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody)), URL: urlValue}
+		return newAPIError(resp.StatusCode, respBody, urlValue)
 	}
 	if out == nil {
 		return nil
@@ -101,3 +172,74 @@ func (c *Client) doJSON(ctx context.Context, method, path string, query url.Valu
 	}
 	return nil
 }
+
+func (c *Client) newRequest(ctx context.Context, method, urlValue string, payload []byte) (*http.Request, error) {
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, urlValue, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if key := idempotencyKey(ctx); key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	return req, nil
+}
+
+// AuthMiddleware sets the API key or OAuth2 bearer token and the
+// X-Redmine-Switch-User header. With OAuth2 a 401 refreshes the token and
+// sends the request once more.
+func (c *Client) AuthMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, accessToken, err := c.authorize(next, req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || c.OAuth == nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if _, err := c.OAuth.Refresh(req.Context(), accessToken); err != nil {
+			return nil, err
+		}
+		again, err := rewind(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, _, err = c.authorize(next, again)
+		return resp, err
+	})
+}
+
+func (c *Client) authorize(next http.RoundTripper, req *http.Request) (*http.Response, string, error) {
+	authorized := req.Clone(req.Context())
+	accessToken := ""
+	if c.OAuth != nil {
+		token, err := c.OAuth.Token(req.Context())
+		if err != nil {
+			return nil, "", err
+		}
+		accessToken = token.AccessToken
+		authorized.Header.Set("Authorization", "Bearer "+accessToken)
+	} else {
+		authorized.Header.Set("X-Redmine-API-Key", c.APIKey)
+	}
+	if c.SwitchUser != "" {
+		authorized.Header.Set("X-Redmine-Switch-User", c.SwitchUser)
+	}
+	resp, err := next.RoundTrip(authorized)
+	return resp, accessToken, err
+}
+
+func (c *Client) wait(ctx context.Context, delay time.Duration) error {
+	if c.sleep != nil {
+		return c.sleep(ctx, delay)
+	}
+	return sleepContext(ctx, delay)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type ErrorKind string
+
+const (
+	ErrorKindAuth       ErrorKind = "auth"
+	ErrorKindForbidden  ErrorKind = "forbidden"
+	ErrorKindNotFound   ErrorKind = "not_found"
+	ErrorKindValidation ErrorKind = "validation"
+	ErrorKindConflict   ErrorKind = "conflict"
+	ErrorKindRateLimit  ErrorKind = "rate_limit"
+	ErrorKindServer     ErrorKind = "server"
+	ErrorKindOther      ErrorKind = "other"
+)
+
+type APIError struct {
+	StatusCode int
+	Body       string
+	URL        string
+	Errors     []string
+}
+
+func newAPIError(status int, body []byte, url string) APIError {
+	return APIError{
+		StatusCode: status,
+		Body:       strings.TrimSpace(string(body)),
+		URL:        url,
+		Errors:     parseErrorMessages(body),
+	}
+}
+
+func (err APIError) Error() string {
+	if len(err.Errors) > 0 {
+		return fmt.Sprintf("api error %d: %s", err.StatusCode, strings.Join(err.Errors, "; "))
+	}
+	if err.Body == "" {
+		return fmt.Sprintf("api error %d", err.StatusCode)
+	}
+	return fmt.Sprintf("api error %d: %s", err.StatusCode, err.Body)
+}
+
+func (err APIError) Kind() ErrorKind {
+	switch {
+	case err.StatusCode == http.StatusUnauthorized:
+		return ErrorKindAuth
+	case err.StatusCode == http.StatusForbidden:
+		return ErrorKindForbidden
+	case err.StatusCode == http.StatusNotFound:
+		return ErrorKindNotFound
+	case err.StatusCode == http.StatusUnprocessableEntity:
+		return ErrorKindValidation
+	case err.StatusCode == http.StatusBadRequest && len(err.Errors) > 0:
+		return ErrorKindValidation
+	case err.StatusCode == http.StatusConflict:
+		return ErrorKindConflict
+	case err.StatusCode == http.StatusTooManyRequests:
+		return ErrorKindRateLimit
+	case err.StatusCode >= http.StatusInternalServerError:
+		return ErrorKindServer
+	}
+	return ErrorKindOther
+}
+
+// parseErrorMessages understands {"errors":["..."]}, the per-field variant
+// {"errors":{"subject":["cannot be blank"]}} and a single {"error":"..."}.
+func parseErrorMessages(body []byte) []string {
+	var envelope struct {
+		Errors json.RawMessage `json:"errors"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(envelope.Errors, &list); err == nil && len(list) > 0 {
+		return list
+	}
+
+	var fields map[string][]string
+	if err := json.Unmarshal(envelope.Errors, &fields); err == nil && len(fields) > 0 {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var messages []string
+		for _, name := range names {
+			for _, message := range fields[name] {
+				messages = append(messages, strings.TrimSpace(name+" "+message))
+			}
+		}
+		return messages
+	}
+
+	if strings.TrimSpace(envelope.Error) != "" {
+		return []string{strings.TrimSpace(envelope.Error)}
+	}
+	return nil
+}
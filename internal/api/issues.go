@@ -24,14 +24,32 @@ type IssueListParams struct {
 }
 
 func (c *Client) ListIssues(ctx context.Context, params IssueListParams) (IssueListResponse, error) {
-	query := url.Values{}
-	hasFilter := false
+	query := issueQuery(params)
 	if params.Limit > 0 {
 		query.Set("limit", strconv.Itoa(params.Limit))
 	}
 	if params.Offset > 0 {
 		query.Set("offset", strconv.Itoa(params.Offset))
 	}
+
+	var resp IssueListResponse
+	if err := c.doJSON(ctx, "GET", "/issues.json", query, nil, &resp); err != nil {
+		return IssueListResponse{}, err
+	}
+	return resp, nil
+}
+
+// IssuePages pages through the issues matching params. Limit and Offset of
+// params are ignored; use PageOptions instead.
+func (c *Client) IssuePages(params IssueListParams) PageFetcher[Issue] {
+	return listPage(c, "/issues.json", issueQuery(params), func(resp IssueListResponse) Page[Issue] {
+		return Page[Issue]{Items: resp.Issues, TotalCount: resp.TotalCount, Offset: resp.Offset, Limit: resp.Limit}
+	})
+}
+
+func issueQuery(params IssueListParams) url.Values {
+	query := url.Values{}
+	hasFilter := false
 	if strings.TrimSpace(params.Sort) != "" {
 		query.Set("sort", params.Sort)
 	}
@@ -74,12 +92,7 @@ func (c *Client) ListIssues(ctx context.Context, params IssueListParams) (IssueL
 	if len(params.Include) > 0 {
 		query.Set("include", strings.Join(params.Include, ","))
 	}
-
-	var resp IssueListResponse
-	if err := c.doJSON(ctx, "GET", "/issues.json", query, nil, &resp); err != nil {
-		return IssueListResponse{}, err
-	}
-	return resp, nil
+	return query
 }
 
 func (c *Client) CreateIssue(ctx context.Context, input IssueInput) (IssueResponse, error) {
@@ -103,3 +116,25 @@ func (c *Client) UpdateIssue(ctx context.Context, id int, input IssueInput) (Iss
 	}
 	return resp, nil
 }
+
+func (c *Client) GetIssue(ctx context.Context, id int, include ...string) (IssueResponse, error) {
+	if id == 0 {
+		return IssueResponse{}, fmt.Errorf("missing issue id")
+	}
+	var query url.Values
+	if len(include) > 0 {
+		query = url.Values{"include": {strings.Join(include, ",")}}
+	}
+	var resp IssueResponse
+	if err := c.doJSON(ctx, "GET", fmt.Sprintf("/issues/%d.json", id), query, nil, &resp); err != nil {
+		return IssueResponse{}, err
+	}
+	return resp, nil
+}
+
+func (c *Client) DeleteIssue(ctx context.Context, id int) error {
+	if id == 0 {
+		return fmt.Errorf("missing issue id")
+	}
+	return c.doJSON(ctx, "DELETE", fmt.Sprintf("/issues/%d.json", id), nil, nil, nil)
+}
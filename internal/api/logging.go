@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+var sensitiveHeaders = map[string]bool{
+	"X-Redmine-Api-Key": true,
+	"Authorization":     true,
+	"Cookie":            true,
+	"Set-Cookie":        true,
+}
+
+// LoggingTransport writes one line per request and response to Out. With Trace
+// set it also dumps headers and bodies. Credentials are always redacted.
+type LoggingTransport struct {
+	Base  http.RoundTripper
+	Out   io.Writer
+	Trace bool
+
+	mu sync.Mutex
+}
+
+// Logging returns a middleware that logs like LoggingTransport.
+func Logging(out io.Writer, trace bool) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &LoggingTransport{Base: next, Out: out, Trace: trace}
+	}
+}
+
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, requestBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := base(t.Base).RoundTrip(req)
+	elapsed := time.Since(start)
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("> %s %s", req.Method, req.URL.String()))
+	if t.Trace {
+		lines = append(lines, headerLines("> ", req.Header)...)
+		lines = append(lines, bodyLines("> ", requestBody)...)
+	}
+	if err != nil {
+		lines = append(lines, fmt.Sprintf("< error: %v (%s)", err, formatElapsed(elapsed)))
+		t.write(lines)
+		return nil, err
+	}
+
+	lines = append(lines, fmt.Sprintf("< %s (%s)", resp.Status, formatElapsed(elapsed)))
+	if t.Trace {
+		responseBody, readErr := readResponseBody(resp)
+		if readErr != nil {
+			t.write(lines)
+			return nil, readErr
+		}
+		lines = append(lines, headerLines("< ", resp.Header)...)
+		lines = append(lines, bodyLines("< ", responseBody)...)
+	}
+	t.write(lines)
+	return resp, nil
+}
+
+func (t *LoggingTransport) write(lines []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, line := range lines {
+		fmt.Fprintln(t.Out, line)
+	}
+}
+
+func base(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		return http.DefaultTransport
+	}
+	return transport
+}
+
+// readRequestBody returns the body of req and the request to send on. The
+// body is read through GetBody when possible, leaving req as is; otherwise
+// the request sent on is a clone with the body buffered, since a
+// RoundTripper must not modify the request it is given.
+func readRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		return req, data, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(data))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return clone, data, nil
+}
+
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func redactHeader(name, value string) string {
+	if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+		return redacted
+	}
+	return value
+}
+
+func headerLines(prefix string, header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		for _, value := range header[name] {
+			lines = append(lines, fmt.Sprintf("%s%s: %s", prefix, name, redactHeader(name, value)))
+		}
+	}
+	return lines
+}
+
+func bodyLines(prefix string, body []byte) []string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "  "); err == nil {
+		body = pretty.Bytes()
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(body), "\n"), "\n") {
+		lines = append(lines, prefix+line)
+	}
+	return lines
+}
+
+func formatElapsed(elapsed time.Duration) string {
+	return elapsed.Round(time.Millisecond).String()
+}
@@ -2,8 +2,8 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/url"
-	"strconv"
 )
 
 func (c *Client) ListTrackers(ctx context.Context) ([]Tracker, error) {
@@ -31,51 +31,42 @@ func (c *Client) ListIssuePriorities(ctx context.Context) ([]IssuePriority, erro
 }
 
 func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
-	return listUsersPaged(ctx, c)
+	return CollectAll(ctx, c.UserPages(), PageOptions{})
 }
 
 func (c *Client) ListProjects(ctx context.Context) ([]Project, error) {
-	return listProjectsPaged(ctx, c)
+	return CollectAll(ctx, c.ProjectPages(), PageOptions{})
 }
 
-func listUsersPaged(ctx context.Context, c *Client) ([]User, error) {
-	limit := 100
-	offset := 0
-	var all []User
-	for {
-		query := url.Values{}
-		query.Set("limit", strconv.Itoa(limit))
-		query.Set("offset", strconv.Itoa(offset))
-		var resp UserListResponse
-		if err := c.doJSON(ctx, "GET", "/users.json", query, nil, &resp); err != nil {
-			return nil, err
-		}
-		all = append(all, resp.Users...)
-		offset += resp.Limit
-		if offset >= resp.TotalCount || resp.Limit == 0 {
-			break
-		}
+func (c *Client) UserPages() PageFetcher[User] {
+	return listPage(c, "/users.json", nil, func(resp UserListResponse) Page[User] {
+		return Page[User]{Items: resp.Users, TotalCount: resp.TotalCount, Offset: resp.Offset, Limit: resp.Limit}
+	})
+}
+
+func (c *Client) ProjectPages() PageFetcher[Project] {
+	return listPage(c, "/projects.json", nil, func(resp ProjectListResponse) Page[Project] {
+		return Page[Project]{Items: resp.Projects, TotalCount: resp.TotalCount, Offset: resp.Offset, Limit: resp.Limit}
+	})
+}
+
+func (c *Client) SearchUsers(ctx context.Context, name string) ([]User, error) {
+	query := url.Values{}
+	query.Set("name", name)
+	query.Set("limit", "100")
+	var resp UserListResponse
+	if err := c.doJSON(ctx, "GET", "/users.json", query, nil, &resp); err != nil {
+		return nil, err
 	}
-	return all, nil
+	return resp.Users, nil
 }
 
-func listProjectsPaged(ctx context.Context, c *Client) ([]Project, error) {
-	limit := 100
-	offset := 0
-	var all []Project
-	for {
-		query := url.Values{}
-		query.Set("limit", strconv.Itoa(limit))
-		query.Set("offset", strconv.Itoa(offset))
-		var resp ProjectListResponse
-		if err := c.doJSON(ctx, "GET", "/projects.json", query, nil, &resp); err != nil {
-			return nil, err
-		}
-		all = append(all, resp.Projects...)
-		offset += resp.Limit
-		if offset >= resp.TotalCount || resp.Limit == 0 {
-			break
-		}
+func (c *Client) ListProjectMemberships(ctx context.Context, projectID int) ([]Membership, error) {
+	if projectID == 0 {
+		return nil, fmt.Errorf("missing project id")
 	}
-	return all, nil
+	path := fmt.Sprintf("/projects/%d/memberships.json", projectID)
+	return CollectAll(ctx, listPage(c, path, nil, func(resp MembershipListResponse) Page[Membership] {
+		return Page[Membership]{Items: resp.Memberships, TotalCount: resp.TotalCount, Offset: resp.Offset, Limit: resp.Limit}
+	}), PageOptions{})
 }
@@ -0,0 +1,95 @@
+package api
+
+import "net/http"
+
+const DefaultUserAgent = "easy8-cli"
+
+// Middleware wraps the next RoundTripper of a Client's chain. Like any
+// RoundTripper it must not modify the request it is given; clone it first.
+//
+// A middleware that adds a header and counts requests:
+//
+//	client.Use(func(next http.RoundTripper) http.RoundTripper {
+//		return api.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+//			req = req.Clone(req.Context())
+//			req.Header.Set("X-Request-Source", "billing-sync")
+//			requests.Add(1)
+//			return next.RoundTrip(req)
+//		})
+//	})
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// DefaultMiddleware is the built-in chain, outermost first: user agent,
+// retry, auth and rate limit. The middlewares read the client's fields on
+// every request, so later changes to Retry, Limiter or SwitchUser apply.
+func (c *Client) DefaultMiddleware() []Middleware {
+	return []Middleware{
+		UserAgent(c.UserAgent),
+		c.RetryMiddleware,
+		c.AuthMiddleware,
+		c.RateLimitMiddleware,
+	}
+}
+
+// Use appends middleware at the inner end of the chain, next to the network,
+// where it sees every retry attempt with its final headers. To run elsewhere,
+// for example once per call outside the retries, edit c.Middleware directly.
+func (c *Client) Use(middleware ...Middleware) {
+	if c.Middleware == nil {
+		c.Middleware = c.DefaultMiddleware()
+	}
+	c.Middleware = append(c.Middleware, middleware...)
+}
+
+// UserAgent sets the User-Agent header unless the request already has one.
+func UserAgent(agent string) Middleware {
+	if agent == "" {
+		agent = DefaultUserAgent
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("User-Agent") != "" {
+				return next.RoundTrip(req)
+			}
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", agent)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func (c *Client) handler() http.RoundTripper {
+	middleware := c.Middleware
+	if middleware == nil {
+		middleware = c.DefaultMiddleware()
+	}
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	var next http.RoundTripper = RoundTripperFunc(httpClient.Do)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+	return next
+}
+
+// rewind returns a copy of req with a fresh body so it can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil {
+		return clone, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+	return clone, nil
+}
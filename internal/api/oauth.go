@@ -0,0 +1,365 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotLoggedIn = errors.New("not logged in; run 'easy8 auth login'")
+
+type OAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	AuthorizeURL string
+	TokenURL     string
+	Scopes       []string
+	RedirectPort int
+}
+
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	ExpiresIn    int       `json:"expires_in,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+func (token Token) Expired(now time.Time) bool {
+	if token.Expiry.IsZero() {
+		return false
+	}
+	return !now.Add(30 * time.Second).Before(token.Expiry)
+}
+
+type TokenStore interface {
+	Load() (Token, error)
+	Save(token Token) error
+	Delete() error
+}
+
+type FileTokenStore struct {
+	Path string
+}
+
+func (store FileTokenStore) Load() (Token, error) {
+	if store.Path == "" {
+		return Token{}, ErrNotLoggedIn
+	}
+	data, err := os.ReadFile(store.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Token{}, ErrNotLoggedIn
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return Token{}, err
+	}
+	if token.AccessToken == "" {
+		return Token{}, ErrNotLoggedIn
+	}
+	return token, nil
+}
+
+func (store FileTokenStore) Save(token Token) error {
+	if store.Path == "" {
+		return fmt.Errorf("missing token path")
+	}
+	if err := os.MkdirAll(filepath.Dir(store.Path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(store.Path, data, 0o600)
+}
+
+func (store FileTokenStore) Delete() error {
+	if store.Path == "" {
+		return nil
+	}
+	err := os.Remove(store.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+type OAuth struct {
+	Config OAuthConfig
+	Store  TokenStore
+	HTTP   *http.Client
+
+	mu    sync.Mutex
+	token *Token
+	now   func() time.Time
+}
+
+func NewOAuth(baseURL string, cfg OAuthConfig, store TokenStore) *OAuth {
+	base := strings.TrimRight(baseURL, "/")
+	if cfg.AuthorizeURL == "" {
+		cfg.AuthorizeURL = base + "/oauth/authorize"
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = base + "/oauth/token"
+	}
+	return &OAuth{
+		Config: cfg,
+		Store:  store,
+		HTTP:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (o *OAuth) Token(ctx context.Context) (Token, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token == nil {
+		token, err := o.Store.Load()
+		if err != nil {
+			return Token{}, err
+		}
+		o.token = &token
+	}
+	if o.token.Expired(o.clock()) && o.token.RefreshToken != "" {
+		if err := o.refreshLocked(ctx); err != nil {
+			return Token{}, err
+		}
+	}
+	return *o.token, nil
+}
+
+// Refresh exchanges the refresh token unless another caller already replaced
+// the rejected access token in the meantime.
+func (o *OAuth) Refresh(ctx context.Context, rejected string) (Token, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token == nil {
+		token, err := o.Store.Load()
+		if err != nil {
+			return Token{}, err
+		}
+		o.token = &token
+	}
+	if o.token.AccessToken != rejected {
+		return *o.token, nil
+	}
+	if err := o.refreshLocked(ctx); err != nil {
+		return Token{}, err
+	}
+	return *o.token, nil
+}
+
+func (o *OAuth) refreshLocked(ctx context.Context) error {
+	if o.token.RefreshToken == "" {
+		return fmt.Errorf("oauth session expired; run 'easy8 auth login'")
+	}
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", o.token.RefreshToken)
+	token, err := o.requestToken(ctx, form)
+	if err != nil {
+		return fmt.Errorf("oauth refresh failed: %w", err)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = o.token.RefreshToken
+	}
+	if err := o.Store.Save(token); err != nil {
+		return err
+	}
+	o.token = &token
+	return nil
+}
+
+func (o *OAuth) Login(ctx context.Context, open func(authURL string) error) (Token, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", o.Config.RedirectPort))
+	if err != nil {
+		return Token{}, err
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d/callback", port)
+
+	verifier, err := randomString(32)
+	if err != nil {
+		_ = listener.Close()
+		return Token{}, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		_ = listener.Close()
+		return Token{}, err
+	}
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		var result callbackResult
+		switch {
+		case query.Get("state") != state:
+			result.err = fmt.Errorf("oauth callback state mismatch")
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("oauth authorization failed: %s %s", query.Get("error"), query.Get("error_description"))
+		case query.Get("code") == "":
+			result.err = fmt.Errorf("oauth callback without code")
+		default:
+			result.code = query.Get("code")
+		}
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			_, _ = io.WriteString(w, "Login successful. You can close this window.\n")
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	if err := open(o.authorizeURL(redirectURI, state, pkceChallenge(verifier))); err != nil {
+		return Token{}, err
+	}
+
+	var result callbackResult
+	select {
+	case <-ctx.Done():
+		return Token{}, ctx.Err()
+	case result = <-results:
+	}
+	if result.err != nil {
+		return Token{}, result.err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", result.code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", verifier)
+	token, err := o.requestToken(ctx, form)
+	if err != nil {
+		return Token{}, fmt.Errorf("oauth token exchange failed: %w", err)
+	}
+	if err := o.Store.Save(token); err != nil {
+		return Token{}, err
+	}
+	o.mu.Lock()
+	o.token = &token
+	o.mu.Unlock()
+	return token, nil
+}
+
+func (o *OAuth) Logout() error {
+	o.mu.Lock()
+	o.token = nil
+	o.mu.Unlock()
+	return o.Store.Delete()
+}
+
+func (o *OAuth) authorizeURL(redirectURI, state, challenge string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", o.Config.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("state", state)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", "S256")
+	if len(o.Config.Scopes) > 0 {
+		query.Set("scope", strings.Join(o.Config.Scopes, " "))
+	}
+	separator := "?"
+	if strings.Contains(o.Config.AuthorizeURL, "?") {
+		separator = "&"
+	}
+	return o.Config.AuthorizeURL + separator + query.Encode()
+}
+
+func (o *OAuth) requestToken(ctx context.Context, form url.Values) (Token, error) {
+	form.Set("client_id", o.Config.ClientID)
+	if o.Config.ClientSecret != "" {
+		form.Set("client_secret", o.Config.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", o.Config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.HTTP.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(respBody, &oauthErr) == nil && oauthErr.Error != "" {
+			return Token{}, fmt.Errorf("%s: %s", oauthErr.Error, oauthErr.Description)
+		}
+		return Token{}, newAPIError(resp.StatusCode, respBody, o.Config.TokenURL)
+	}
+
+	var token Token
+	if err := json.Unmarshal(respBody, &token); err != nil {
+		return Token{}, err
+	}
+	if token.AccessToken == "" {
+		return Token{}, fmt.Errorf("token response without access_token")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = o.clock().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+func (o *OAuth) clock() time.Time {
+	if o.now != nil {
+		return o.now()
+	}
+	return time.Now()
+}
+
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+const DefaultPageSize = 100
+
+// ErrStopPaging can be returned from a Paginate callback to end the iteration
+// early without an error.
+var ErrStopPaging = errors.New("stop paging")
+
+type Page[T any] struct {
+	Items      []T
+	TotalCount int
+	Offset     int
+	Limit      int
+}
+
+// PageFetcher requests one page. The server may return fewer items, or use a
+// different limit, than requested.
+type PageFetcher[T any] func(ctx context.Context, offset, limit int) (Page[T], error)
+
+type PageOptions struct {
+	PageSize int
+	Offset   int
+	Max      int
+	Prefetch int
+}
+
+// Paginate calls yield for every item, in order, starting at opts.Offset. It
+// stops after opts.Max items (0 means all), when yield returns an error, or
+// when the server runs out of items. With opts.Prefetch > 1 and a known
+// total, up to that many pages are fetched in parallel once the first page
+// has arrived. The total reported by the server is returned even on error.
+func Paginate[T any](ctx context.Context, fetch PageFetcher[T], opts PageOptions, yield func(T) error) (int, error) {
+	size := opts.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	p := &pager[T]{fetch: fetch, opts: opts, size: size, yield: yield}
+
+	offset := opts.Offset
+	first, err := fetch(ctx, offset, p.requestSize())
+	if err != nil {
+		return 0, err
+	}
+	p.total = first.TotalCount
+	if done, err := p.emit(first.Items); done || err != nil {
+		return p.total, err
+	}
+	offset += len(first.Items)
+	if p.lastPage(first, offset) {
+		return p.total, nil
+	}
+
+	if opts.Prefetch > 1 && p.total > 0 {
+		return p.total, p.prefetch(ctx, offset, len(first.Items))
+	}
+	for {
+		page, err := fetch(ctx, offset, p.requestSize())
+		if err != nil {
+			return p.total, err
+		}
+		if page.TotalCount > 0 {
+			p.total = page.TotalCount
+		}
+		if done, err := p.emit(page.Items); done || err != nil {
+			return p.total, err
+		}
+		offset += len(page.Items)
+		if p.lastPage(page, offset) {
+			return p.total, nil
+		}
+	}
+}
+
+// CollectAll returns every item Paginate yields.
+func CollectAll[T any](ctx context.Context, fetch PageFetcher[T], opts PageOptions) ([]T, error) {
+	var all []T
+	_, err := Paginate(ctx, fetch, opts, func(item T) error {
+		all = append(all, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+type pager[T any] struct {
+	fetch PageFetcher[T]
+	opts  PageOptions
+	size  int
+	yield func(T) error
+	seen  int
+	total int
+}
+
+func (p *pager[T]) requestSize() int {
+	if p.opts.Max > 0 && p.opts.Max-p.seen < p.size {
+		return p.opts.Max - p.seen
+	}
+	return p.size
+}
+
+func (p *pager[T]) emit(items []T) (bool, error) {
+	for _, item := range items {
+		if err := p.yield(item); err != nil {
+			if errors.Is(err, ErrStopPaging) {
+				return true, nil
+			}
+			return true, err
+		}
+		p.seen++
+		if p.opts.Max > 0 && p.seen >= p.opts.Max {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// lastPage reports whether page was the final one. An empty page always ends
+// the iteration so a wrong total_count cannot cause an endless loop. Without a
+// total, a page shorter than the server's own limit is the last one.
+func (p *pager[T]) lastPage(page Page[T], next int) bool {
+	if len(page.Items) == 0 {
+		return true
+	}
+	if p.total > 0 {
+		return next >= p.total
+	}
+	limit := p.size
+	if page.Limit > 0 && page.Limit < limit {
+		limit = page.Limit
+	}
+	return len(page.Items) < limit
+}
+
+// prefetch fetches the remaining pages with up to opts.Prefetch requests in
+// flight and yields them in order. step is the number of items the server
+// actually returned per page, which may be less than the requested size.
+func (p *pager[T]) prefetch(ctx context.Context, offset, step int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var offsets []int
+	for next := offset; next < p.total; next += step {
+		if p.opts.Max > 0 && next-p.opts.Offset >= p.opts.Max {
+			break
+		}
+		offsets = append(offsets, next)
+	}
+
+	type result struct {
+		page Page[T]
+		err  error
+	}
+	results := make([]chan result, len(offsets))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+	slots := make(chan struct{}, p.opts.Prefetch)
+	go func() {
+		for i, next := range offsets {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i, next int) {
+				page, err := p.fetch(ctx, next, step)
+				results[i] <- result{page: page, err: err}
+			}(i, next)
+		}
+	}()
+
+	for i := range offsets {
+		var r result
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		<-slots
+		if r.err != nil {
+			return r.err
+		}
+		if done, err := p.emit(r.page.Items); done || err != nil {
+			return err
+		}
+		if len(r.page.Items) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+func listPage[T any, R any](c *Client, path string, base url.Values, unwrap func(R) Page[T]) PageFetcher[T] {
+	return func(ctx context.Context, offset, limit int) (Page[T], error) {
+		query := url.Values{}
+		for key, values := range base {
+			query[key] = values
+		}
+		query.Set("limit", strconv.Itoa(limit))
+		query.Set("offset", strconv.Itoa(offset))
+		var resp R
+		if err := c.doJSON(ctx, "GET", path, query, nil, &resp); err != nil {
+			return Page[T]{}, err
+		}
+		return unwrap(resp), nil
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Jitter    float64
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:  3,
+		BaseDelay: 500 * time.Millisecond,
+		MaxDelay:  10 * time.Second,
+		Jitter:    0.2,
+	}
+}
+
+type idempotencyKeyContext struct{}
+
+// WithIdempotencyKey marks a request as safe to retry even when its method is
+// not idempotent. The key is sent as the Idempotency-Key header.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContext{}, key)
+}
+
+func idempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContext{}).(string)
+	return key
+}
+
+// RetryMiddleware retries transient failures according to c.Retry, honouring
+// Retry-After. Non-idempotent requests are only retried with an idempotency key.
+func (c *Client) RetryMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		attempts := c.Retry.attempts(ctx, req.Method)
+		attemptReq := req
+		for attempt := 1; ; attempt++ {
+			if attempt > 1 {
+				var err error
+				if attemptReq, err = rewind(req); err != nil {
+					return nil, err
+				}
+			}
+			resp, err := next.RoundTrip(attemptReq)
+			if err != nil {
+				if attempt >= attempts || ctx.Err() != nil || !retryableError(err) {
+					return nil, err
+				}
+				if err := c.wait(ctx, c.Retry.backoff(attempt)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if attempt >= attempts || !retryableStatus(resp.StatusCode) {
+				return resp, nil
+			}
+
+			delay := c.Retry.backoff(attempt)
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if c.Retry.MaxDelay > 0 && retryAfter > c.Retry.MaxDelay {
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if err := c.wait(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+	})
+}
+
+func (policy RetryPolicy) attempts(ctx context.Context, method string) int {
+	if policy.Attempts <= 1 {
+		return 1
+	}
+	if !idempotentMethod(method) && idempotencyKey(ctx) == "" {
+		return 1
+	}
+	return policy.Attempts
+}
+
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < attempt && (policy.MaxDelay <= 0 || delay < policy.MaxDelay); i++ {
+		delay *= 2
+	}
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	if policy.Jitter > 0 {
+		spread := float64(delay) * policy.Jitter
+		delay = time.Duration(float64(delay) - spread + rand.Float64()*2*spread)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// idempotentMethod reports whether a request may be repeated. PUT is left
+// out: an issue update with notes adds a journal entry every time it reaches
+// the server, even when the response is lost.
+func idempotentMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func retryableError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	when, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	delay := when.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
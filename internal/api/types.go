@@ -1,25 +1,88 @@
 package api
 
+import (
+	"fmt"
+	"strings"
+)
+
 type NamedRef struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
 }
 
 type Issue struct {
+	ID             int       `json:"id"`
+	Subject        string    `json:"subject"`
+	Description    string    `json:"description,omitempty"`
+	DoneRatio      int       `json:"done_ratio,omitempty"`
+	EstimatedHours float64   `json:"estimated_hours,omitempty"`
+	SpentHours     float64   `json:"spent_hours,omitempty"`
+	StartDate      string    `json:"start_date,omitempty"`
+	DueDate        string    `json:"due_date,omitempty"`
+	UpdatedOn      string    `json:"updated_on,omitempty"`
+	CreatedOn      string    `json:"created_on,omitempty"`
+	Project        *NamedRef `json:"project,omitempty"`
+	Tracker        *NamedRef `json:"tracker,omitempty"`
+	Status         *NamedRef `json:"status,omitempty"`
+	Priority       *NamedRef `json:"priority,omitempty"`
+	Author         *NamedRef `json:"author,omitempty"`
+	AssignedTo     *NamedRef `json:"assigned_to,omitempty"`
+
+	CustomFields []CustomField `json:"custom_fields,omitempty"`
+
+	// Journals and Attachments are only sent when requested with include.
+	Journals    []Journal    `json:"journals,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Journal is one change of an issue: the notes and the changed attributes.
+type Journal struct {
+	ID        int             `json:"id"`
+	User      *NamedRef       `json:"user,omitempty"`
+	Notes     string          `json:"notes,omitempty"`
+	CreatedOn string          `json:"created_on,omitempty"`
+	Details   []JournalDetail `json:"details,omitempty"`
+}
+
+// JournalDetail is a changed attribute. Property is "attr", "cf" or
+// "attachment"; the values of attributes like status_id are IDs.
+type JournalDetail struct {
+	Property string `json:"property"`
+	Name     string `json:"name"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+}
+
+type Attachment struct {
 	ID          int       `json:"id"`
-	Subject     string    `json:"subject"`
+	Filename    string    `json:"filename"`
+	Filesize    int64     `json:"filesize"`
+	ContentType string    `json:"content_type,omitempty"`
 	Description string    `json:"description,omitempty"`
-	DoneRatio   int       `json:"done_ratio,omitempty"`
-	StartDate   string    `json:"start_date,omitempty"`
-	DueDate     string    `json:"due_date,omitempty"`
-	UpdatedOn   string    `json:"updated_on,omitempty"`
-	CreatedOn   string    `json:"created_on,omitempty"`
-	Project     *NamedRef `json:"project,omitempty"`
-	Tracker     *NamedRef `json:"tracker,omitempty"`
-	Status      *NamedRef `json:"status,omitempty"`
-	Priority    *NamedRef `json:"priority,omitempty"`
+	ContentURL  string    `json:"content_url,omitempty"`
 	Author      *NamedRef `json:"author,omitempty"`
-	AssignedTo  *NamedRef `json:"assigned_to,omitempty"`
+	CreatedOn   string    `json:"created_on,omitempty"`
+}
+
+// CustomField holds a string, a list of strings (multiple values) or nil.
+type CustomField struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+func (f CustomField) String() string {
+	switch value := f.Value.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, 0, len(value))
+		for _, item := range value {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(f.Value)
 }
 
 type IssueInput struct {
@@ -78,8 +141,9 @@ type TrackerListResponse struct {
 }
 
 type IssueStatus struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	IsClosed bool   `json:"is_closed,omitempty"`
 }
 
 type IssueStatusListResponse struct {
@@ -87,8 +151,9 @@ type IssueStatusListResponse struct {
 }
 
 type IssuePriority struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default,omitempty"`
 }
 
 type IssuePriorityListResponse struct {
@@ -120,3 +185,18 @@ type ProjectListResponse struct {
 	Offset     int       `json:"offset"`
 	Limit      int       `json:"limit"`
 }
+
+type Membership struct {
+	ID      int        `json:"id"`
+	Project *NamedRef  `json:"project,omitempty"`
+	User    *NamedRef  `json:"user,omitempty"`
+	Group   *NamedRef  `json:"group,omitempty"`
+	Roles   []NamedRef `json:"roles,omitempty"`
+}
+
+type MembershipListResponse struct {
+	Memberships []Membership `json:"memberships"`
+	TotalCount  int          `json:"total_count"`
+	Offset      int          `json:"offset"`
+	Limit       int          `json:"limit"`
+}
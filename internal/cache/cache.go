@@ -0,0 +1,338 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+const DefaultTTL = time.Hour
+
+const (
+	Users      = "users"
+	Projects   = "projects"
+	Statuses   = "statuses"
+	Trackers   = "trackers"
+	Priorities = "priorities"
+)
+
+var Names = []string{Users, Projects, Statuses, Trackers, Priorities}
+
+type Store struct {
+	Dir string
+	TTL time.Duration
+
+	now func() time.Time
+}
+
+type Entry struct {
+	Name      string
+	FetchedAt time.Time
+	Count     int
+	Size      int64
+	Expired   bool
+}
+
+type envelope struct {
+	BaseURL   string          `json:"base_url"`
+	Profile   string          `json:"profile,omitempty"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Count     int             `json:"count"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func Root() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "easy8"), nil
+}
+
+// Dir returns the cache directory of one instance/profile pair. The readable
+// prefix helps when browsing the cache; the hash keeps pairs apart.
+func Dir(baseURL, profile string) (string, error) {
+	root, err := Root()
+	if err != nil {
+		return "", err
+	}
+	host := baseURL
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	if profile == "" {
+		profile = "default"
+	}
+	sum := sha256.Sum256([]byte(strings.TrimRight(baseURL, "/") + "\n" + profile))
+	name := sanitize(host) + "_" + sanitize(profile) + "_" + hex.EncodeToString(sum[:4])
+	return filepath.Join(root, name), nil
+}
+
+func sanitize(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.Dir, name+".json")
+}
+
+func (s *Store) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *Store) ttl() time.Duration {
+	if s.TTL > 0 {
+		return s.TTL
+	}
+	return DefaultTTL
+}
+
+func (s *Store) read(name string) (envelope, error) {
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		return envelope{}, err
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return envelope{}, err
+	}
+	return env, nil
+}
+
+func (s *Store) Get(name string, out any) bool {
+	env, err := s.read(name)
+	if err != nil {
+		return false
+	}
+	if s.clock().Sub(env.FetchedAt) > s.ttl() {
+		return false
+	}
+	return json.Unmarshal(env.Data, out) == nil
+}
+
+func (s *Store) Put(name, baseURL, profile string, count int, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(envelope{BaseURL: baseURL, Profile: profile, FetchedAt: s.clock().UTC(), Count: count, Data: data})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(payload); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(name))
+}
+
+func (s *Store) Delete(name string) error {
+	err := os.Remove(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) Clear() error {
+	return os.RemoveAll(s.Dir)
+}
+
+func (s *Store) Info() ([]Entry, error) {
+	var entries []Entry
+	for _, name := range Names {
+		env, err := s.read(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(s.path(name))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{
+			Name:      name,
+			FetchedAt: env.FetchedAt,
+			Count:     env.Count,
+			Size:      info.Size(),
+			Expired:   s.clock().Sub(env.FetchedAt) > s.ttl(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Source fetches the lookup tables from the API; *api.Client implements it.
+type Source interface {
+	ListUsers(ctx context.Context) ([]api.User, error)
+	ListProjects(ctx context.Context) ([]api.Project, error)
+	ListIssueStatuses(ctx context.Context) ([]api.IssueStatus, error)
+	ListTrackers(ctx context.Context) ([]api.Tracker, error)
+	ListIssuePriorities(ctx context.Context) ([]api.IssuePriority, error)
+}
+
+// Lookups serves the lookup tables of lookups.go from the Store and falls
+// back to Client when an entry is missing or expired. BaseURL is recorded
+// with every stored table.
+type Lookups struct {
+	Client   Source
+	BaseURL  string
+	Store    *Store
+	Profile  string
+	Disabled bool
+
+	mu     sync.Mutex
+	cached map[string]bool
+}
+
+func (l *Lookups) ListUsers(ctx context.Context) ([]api.User, error) {
+	return load(ctx, l, Users, l.Client.ListUsers)
+}
+
+func (l *Lookups) ListProjects(ctx context.Context) ([]api.Project, error) {
+	return load(ctx, l, Projects, l.Client.ListProjects)
+}
+
+func (l *Lookups) ListIssueStatuses(ctx context.Context) ([]api.IssueStatus, error) {
+	return load(ctx, l, Statuses, l.Client.ListIssueStatuses)
+}
+
+func (l *Lookups) ListTrackers(ctx context.Context) ([]api.Tracker, error) {
+	return load(ctx, l, Trackers, l.Client.ListTrackers)
+}
+
+func (l *Lookups) ListIssuePriorities(ctx context.Context) ([]api.IssuePriority, error) {
+	return load(ctx, l, Priorities, l.Client.ListIssuePriorities)
+}
+
+// CachedUsers returns the users table only when a fresh copy is on disk.
+func (l *Lookups) CachedUsers() ([]api.User, bool) {
+	if l.Disabled || l.Store == nil {
+		return nil, false
+	}
+	var users []api.User
+	if !l.Store.Get(Users, &users) {
+		return nil, false
+	}
+	l.markCached(Users)
+	return users, true
+}
+
+func (l *Lookups) markCached(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cached == nil {
+		l.cached = map[string]bool{}
+	}
+	l.cached[name] = true
+}
+
+// Invalidate drops a table that was served from disk so the next call
+// refetches it. It reports whether anything was dropped.
+func (l *Lookups) Invalidate(name string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.cached[name] {
+		return false
+	}
+	delete(l.cached, name)
+	_ = l.Store.Delete(name)
+	return true
+}
+
+// Refresh refetches every table in Names order. On error the counts of the
+// tables refreshed so far are returned alongside it.
+func (l *Lookups) Refresh(ctx context.Context) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, name := range Names {
+		count, err := l.refresh(ctx, name)
+		if err != nil {
+			return counts, err
+		}
+		counts[name] = count
+	}
+	return counts, nil
+}
+
+func (l *Lookups) refresh(ctx context.Context, name string) (int, error) {
+	switch name {
+	case Users:
+		return fetchAndStore(ctx, l, name, l.Client.ListUsers)
+	case Projects:
+		return fetchAndStore(ctx, l, name, l.Client.ListProjects)
+	case Statuses:
+		return fetchAndStore(ctx, l, name, l.Client.ListIssueStatuses)
+	case Trackers:
+		return fetchAndStore(ctx, l, name, l.Client.ListTrackers)
+	case Priorities:
+		return fetchAndStore(ctx, l, name, l.Client.ListIssuePriorities)
+	}
+	return 0, fmt.Errorf("unknown lookup table: %s", name)
+}
+
+func load[T any](ctx context.Context, l *Lookups, name string, fetch func(context.Context) ([]T, error)) ([]T, error) {
+	if !l.Disabled && l.Store != nil {
+		var items []T
+		if l.Store.Get(name, &items) {
+			l.markCached(name)
+			return items, nil
+		}
+	}
+	items, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !l.Disabled && l.Store != nil {
+		_ = l.Store.Put(name, l.BaseURL, l.Profile, len(items), items)
+	}
+	return items, nil
+}
+
+func fetchAndStore[T any](ctx context.Context, l *Lookups, name string, fetch func(context.Context) ([]T, error)) (int, error) {
+	items, err := fetch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := l.Store.Put(name, l.BaseURL, l.Profile, len(items), items); err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+func TestDirSeparatesInstancesAndProfiles(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	first, err := Dir("https://demo.easysoftware.com", "")
+	if err != nil {
+		t.Fatalf("Dir error: %v", err)
+	}
+	second, _ := Dir("https://demo.easysoftware.com", "customer")
+	third, _ := Dir("https://other.example", "")
+	if first == second || first == third {
+		t.Fatalf("dirs collide: %s %s %s", first, second, third)
+	}
+	if filepath.Base(filepath.Dir(first)) != "easy8" {
+		t.Fatalf("unexpected root: %s", first)
+	}
+}
+
+func TestStoreExpiresEntries(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &Store{Dir: t.TempDir(), TTL: time.Minute, now: func() time.Time { return now }}
+
+	if err := store.Put(Trackers, "https://example.com", "", 1, []api.Tracker{{ID: 1, Name: "Task"}}); err != nil {
+		t.Fatalf("Put error: %v", err)
+	}
+	var trackers []api.Tracker
+	if !store.Get(Trackers, &trackers) || len(trackers) != 1 {
+		t.Fatalf("expected fresh entry: %v", trackers)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if store.Get(Trackers, &trackers) {
+		t.Fatalf("expected expired entry")
+	}
+	entries, err := store.Info()
+	if err != nil || len(entries) != 1 || !entries[0].Expired || entries[0].Count != 1 {
+		t.Fatalf("entries: %+v %v", entries, err)
+	}
+
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear error: %v", err)
+	}
+	if _, err := os.Stat(store.Dir); !os.IsNotExist(err) {
+		t.Fatalf("expected cache dir removed: %v", err)
+	}
+}
+
+func TestLookupsServeFromCacheAndInvalidate(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issue_statuses\":[{\"id\":2,\"name\":\"New\"}]}"))
+	}))
+	defer server.Close()
+
+	client := &api.Client{BaseURL: server.URL, APIKey: "key", HTTP: server.Client()}
+	store := &Store{Dir: t.TempDir()}
+
+	first := &Lookups{Client: client, Store: store}
+	if _, err := first.ListIssueStatuses(context.Background()); err != nil {
+		t.Fatalf("ListIssueStatuses: %v", err)
+	}
+	if first.Invalidate(Statuses) {
+		t.Fatalf("freshly fetched table must not be invalidated")
+	}
+
+	second := &Lookups{Client: client, Store: store}
+	statuses, err := second.ListIssueStatuses(context.Background())
+	if err != nil || len(statuses) != 1 || hits != 1 {
+		t.Fatalf("statuses=%v hits=%d err=%v", statuses, hits, err)
+	}
+	if !second.Invalidate(Statuses) {
+		t.Fatalf("expected cached table to be invalidated")
+	}
+	if _, err := second.ListIssueStatuses(context.Background()); err != nil || hits != 2 {
+		t.Fatalf("hits=%d err=%v", hits, err)
+	}
+
+	disabled := &Lookups{Client: client, Store: store, Disabled: true}
+	if _, err := disabled.ListIssueStatuses(context.Background()); err != nil || hits != 3 {
+		t.Fatalf("hits=%d err=%v", hits, err)
+	}
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+	"github.com/Easy8Com/easy8-cli/internal/config"
+)
+
+var openBrowser = func(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	return cmd.Start()
+}
+
+var authCommand = &command{
+	name: "auth",
+	about: []string{
+		"Requires \"auth_mode\": \"oauth2\" and \"oauth.client_id\" in the active profile.",
+	},
+	commands: []*command{
+		{name: "login", summary: "Sign in with OAuth2 (PKCE)", setup: setupAuthLogin, examples: []string{"easy8 --profile customer auth login --no-browser"}},
+		{name: "logout", summary: "Remove the stored OAuth2 token", setup: setupAuthLogout},
+		{name: "status", summary: "Show the OAuth2 login state", setup: setupAuthStatus},
+	},
+}
+
+// oauthClient checks that the active profile uses OAuth2 and builds the
+// client of the auth commands.
+func oauthClient(inv *invocation) (*api.Client, int) {
+	cfg := inv.cfg
+	if cfg.AuthMode != config.AuthModeOAuth2 {
+		return nil, inv.usageError(fmt.Errorf("auth commands require auth_mode %q in the active profile", config.AuthModeOAuth2))
+	}
+	if cfg.OAuth.ClientID == "" {
+		return nil, inv.usageError(fmt.Errorf("oauth.client_id is not configured"))
+	}
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return nil, inv.configError(err)
+	}
+	return client, 0
+}
+
+func setupAuthLogin(fs *flag.FlagSet, inv *invocation) func() int {
+	noBrowser := fs.Bool("no-browser", false, "Print the authorization URL without opening a browser")
+	timeout := fs.Duration("wait", 5*time.Minute, "How long to wait for the browser callback")
+
+	return func() int {
+		client, code := oauthClient(inv)
+		if code != 0 {
+			return code
+		}
+		ctx, stdout := inv.ctx, inv.globals.stdout
+		waitCtx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+
+		open := func(authURL string) error {
+			fmt.Fprintln(os.Stderr, "Open this URL in your browser to sign in:")
+			fmt.Fprintln(os.Stderr, "  "+authURL)
+			if *noBrowser {
+				return nil
+			}
+			if err := openBrowser(authURL); err != nil {
+				fmt.Fprintln(os.Stderr, "could not open browser:", err)
+			}
+			return nil
+		}
+
+		token, err := client.OAuth.Login(waitCtx, open)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				return inv.apiError(fmt.Errorf("timed out waiting for the authorization callback"))
+			}
+			return inv.apiError(err)
+		}
+		fmt.Fprintln(stdout, "Logged in.")
+		if !token.Expiry.IsZero() {
+			fmt.Fprintln(stdout, "Access token expires:", token.Expiry.Local().Format(time.RFC3339))
+		}
+		return 0
+	}
+}
+
+func setupAuthLogout(fs *flag.FlagSet, inv *invocation) func() int {
+	return func() int {
+		client, code := oauthClient(inv)
+		if code != 0 {
+			return code
+		}
+		if err := client.OAuth.Logout(); err != nil {
+			return inv.apiError(err)
+		}
+		fmt.Fprintln(inv.globals.stdout, "Logged out.")
+		return 0
+	}
+}
+
+func setupAuthStatus(fs *flag.FlagSet, inv *invocation) func() int {
+	return func() int {
+		client, code := oauthClient(inv)
+		if code != 0 {
+			return code
+		}
+		stdout := inv.globals.stdout
+		token, err := client.OAuth.Store.Load()
+		if err != nil {
+			return inv.apiError(err)
+		}
+		fmt.Fprintln(stdout, "Logged in.")
+		if !token.Expiry.IsZero() {
+			state := "valid"
+			if token.Expired(time.Now()) {
+				state = "expired"
+			}
+			fmt.Fprintf(stdout, "Access token %s until %s\n", state, token.Expiry.Local().Format(time.RFC3339))
+		}
+		if token.RefreshToken != "" {
+			fmt.Fprintln(stdout, "Refresh token: present")
+		}
+		return 0
+	}
+}
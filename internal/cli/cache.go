@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Easy8Com/easy8-cli/internal/cache"
+)
+
+var cacheCommand = &command{
+	name: "cache",
+	about: []string{
+		"Lookup tables are cached per base URL and profile under $XDG_CACHE_HOME/easy8.",
+		"Set \"cache_ttl\" (seconds, default 3600) in the config or pass --no-cache to bypass.",
+	},
+	commands: []*command{
+		{name: "refresh", summary: "Refetch users, projects, statuses, trackers and priorities", setup: setupCacheRefresh},
+		{name: "clear", summary: "Delete the lookup cache (--all for every instance)", setup: setupCacheClear, examples: []string{"easy8 cache clear --all"}},
+		{name: "info", summary: "Show cached lookup tables and their age", setup: setupCacheInfo, examples: []string{"easy8 cache info --json"}},
+	},
+}
+
+type refreshedTable struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+type cacheTable struct {
+	Name      string    `json:"name"`
+	Entries   int       `json:"entries"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Expired   bool      `json:"expired"`
+}
+
+type cacheInfo struct {
+	Directory  string       `json:"directory"`
+	TTLSeconds int          `json:"ttl_seconds"`
+	Tables     []cacheTable `json:"tables"`
+}
+
+func setupCacheRefresh(fs *flag.FlagSet, inv *invocation) func() int {
+	out := addOutputFlags(fs, inv)
+
+	return func() int {
+		cfg := inv.cfg
+		output, err := out.printer(cfg)
+		if err != nil {
+			return inv.usageError(err)
+		}
+
+		client, err := newClient(cfg, *inv.globals)
+		if err != nil {
+			return inv.configError(err)
+		}
+		lookups := newLookups(cfg, *inv.globals, client)
+		if lookups.Store == nil {
+			return inv.apiError(fmt.Errorf("cache directory is not available"))
+		}
+
+		counts, err := lookups.Refresh(inv.ctx)
+		if err != nil && !inv.interrupted(err) {
+			return inv.apiError(err)
+		}
+		tables := []refreshedTable{}
+		doc := document{columns: []string{"Table", "Entries"}, records: []any{}}
+		for _, name := range cache.Names {
+			if count, ok := counts[name]; ok {
+				table := refreshedTable{Name: name, Entries: count}
+				tables = append(tables, table)
+				doc.records = append(doc.records, table)
+				doc.rows = append(doc.rows, []string{name, strconv.Itoa(count)})
+			}
+		}
+		doc.value = tables
+		if code := output.print(doc); code != 0 {
+			return code
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "refreshed %d of %d tables\n", len(counts), len(cache.Names))
+			return inv.apiError(err)
+		}
+		return 0
+	}
+}
+
+func setupCacheClear(fs *flag.FlagSet, inv *invocation) func() int {
+	all := fs.Bool("all", false, "Clear the cache of every instance and profile")
+
+	return func() int {
+		cfg := inv.cfg
+		dir, err := cache.Dir(cfg.BaseURL, cfg.Profile)
+		if *all {
+			dir, err = cache.Root()
+		}
+		if err != nil {
+			return inv.apiError(err)
+		}
+		store := &cache.Store{Dir: dir}
+		if err := store.Clear(); err != nil {
+			return inv.apiError(err)
+		}
+		fmt.Fprintln(inv.globals.stdout, "Cleared", dir)
+		return 0
+	}
+}
+
+func setupCacheInfo(fs *flag.FlagSet, inv *invocation) func() int {
+	out := addOutputFlags(fs, inv)
+
+	return func() int {
+		cfg := inv.cfg
+		output, err := out.printer(cfg)
+		if err != nil {
+			return inv.usageError(err)
+		}
+
+		dir, err := cache.Dir(cfg.BaseURL, cfg.Profile)
+		if err != nil {
+			return inv.apiError(err)
+		}
+		store := &cache.Store{Dir: dir, TTL: time.Duration(cfg.CacheTTL) * time.Second}
+		entries, err := store.Info()
+		if err != nil {
+			return inv.apiError(err)
+		}
+
+		ttl := cache.DefaultTTL
+		if cfg.CacheTTL > 0 {
+			ttl = time.Duration(cfg.CacheTTL) * time.Second
+		}
+		info := cacheInfo{Directory: dir, TTLSeconds: int(ttl / time.Second), Tables: []cacheTable{}}
+		doc := document{notes: []string{"Directory: " + dir, "TTL: " + ttl.String()}, records: []any{}}
+		for _, entry := range entries {
+			table := cacheTable{Name: entry.Name, Entries: entry.Count, FetchedAt: entry.FetchedAt, Expired: entry.Expired}
+			info.Tables = append(info.Tables, table)
+			doc.records = append(doc.records, table)
+			state := "fresh"
+			if entry.Expired {
+				state = "expired"
+			}
+			doc.rows = append(doc.rows, []string{entry.Name, strconv.Itoa(entry.Count), entry.FetchedAt.Local().Format(time.RFC3339), state})
+		}
+		doc.value, doc.key = info, "tables"
+		if len(entries) == 0 {
+			doc.notes = append(doc.notes, "No cached lookups.")
+		}
+		if len(entries) > 0 || output.format != "table" {
+			doc.columns = []string{"Table", "Entries", "Fetched", "State"}
+		}
+		return output.print(doc)
+	}
+}
@@ -5,275 +5,572 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
-	"easy8-cli/internal/api"
-	"easy8-cli/internal/config"
+	"github.com/Easy8Com/easy8-cli/internal/api"
+	"github.com/Easy8Com/easy8-cli/internal/cache"
+	"github.com/Easy8Com/easy8-cli/internal/config"
+	"github.com/Easy8Com/easy8-cli/pkg/easy8"
 )
 
+type globalOptions struct {
+	profile        string
+	asUser         string
+	retryAttempts  int
+	rps            float64
+	concurrency    int
+	verbose        bool
+	trace          bool
+	harPath        string
+	caFile         string
+	clientCert     string
+	clientKey      string
+	insecure       bool
+	proxyURL       string
+	requestTimeout time.Duration
+	timeout        time.Duration
+	noCache        bool
+	recordDir      string
+	replayDir      string
+	output         string
+	template       string
+	templateFile   string
+	wide           bool
+	query          string
+	color          string
+	noPager        bool
+
+	har    *api.HARRecorder
+	stdout io.Writer
+}
+
 func Run(args []string) int {
-	cfg, err := config.Load()
+	return run(args, os.Stdout)
+}
+
+// run is Run with the destination of command output (not of diagnostics,
+// which go to stderr) injected.
+func run(args []string, stdout io.Writer) int {
+	globals := &globalOptions{stdout: stdout}
+	inv := &invocation{globals: globals, jsonErrors: jsonOutputArgs(args)}
+	globalFlags := newGlobalFlags(globals)
+	globalFlags.SetOutput(io.Discard)
+	globalFlags.Usage = func() {}
+	cmd, path, args, code, done := resolve(inv, args, globalFlags)
+	if done {
+		return code
+	}
+
+	fs, action := newCommandFlags(cmd, path, inv, globalFlags)
+	positional, err := parseArgs(fs, jsonFieldArgs(args))
+	if err == nil {
+		if output := fs.Lookup("output"); output != nil {
+			inv.jsonErrors = isJSONFormat(output.Value.String())
+		} else {
+			inv.jsonErrors = isJSONFormat(globals.output)
+		}
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		printCommandUsage(cmd, path)
+		return 0
+	}
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "config error:", err)
-		return 1
+		return inv.flagError(path, fs, err)
 	}
-
-	if len(args) == 0 {
-		printUsage()
-		return 2
+	if err := checkArgs(cmd, path, positional); err != nil {
+		return inv.usageError(err)
 	}
+	inv.args = positional
 
-	switch args[0] {
-	case "issue":
-		return runIssue(args[1:], cfg)
-	case "help", "-h", "--help":
-		printUsage()
-		return 0
-	default:
-		fmt.Fprintln(os.Stderr, "unknown command:", args[0])
-		printUsage()
-		return 2
+	cfg, err := config.LoadProfile(globals.profile)
+	if err != nil {
+		return inv.configError(err)
 	}
-}
-
-func runIssue(args []string, cfg config.Config) int {
-	if len(args) == 0 {
-		printIssueUsage()
-		return 2
-	}
-
-	client := api.NewClient(cfg)
-
-	switch args[0] {
-	case "create":
-		return runIssueCreate(args[1:], cfg, client)
-	case "list":
-		return runIssueList(args[1:], cfg, client)
-	case "search":
-		return runIssueSearch(args[1:], cfg, client)
-	case "update":
-		return runIssueUpdate(args[1:], cfg, client)
-	case "help", "-h", "--help":
-		printIssueUsage()
-		return 0
-	default:
-		fmt.Fprintln(os.Stderr, "unknown issue command:", args[0])
-		printIssueUsage()
-		return 2
+	if globals.asUser != "" {
+		cfg.AsUser = globals.asUser
 	}
-}
-
-func runIssueCreate(args []string, cfg config.Config, client *api.Client) int {
-	fs := flag.NewFlagSet("issue create", flag.ContinueOnError)
-	fs.SetOutput(os.Stderr)
-
-	subject := fs.String("subject", "", "Issue subject (required)")
-	description := fs.String("description", "", "Issue description")
-	projectID := fs.Int("project-id", cfg.Defaults.ProjectID, "Project ID")
-	trackerID := fs.Int("tracker-id", cfg.Defaults.TrackerID, "Tracker ID")
-	statusID := fs.Int("status-id", cfg.Defaults.StatusID, "Status ID")
-	priorityID := fs.Int("priority-id", cfg.Defaults.PriorityID, "Priority ID")
-	authorID := fs.Int("author-id", cfg.Defaults.AuthorID, "Author ID")
-	assignedToID := fs.Int("assigned-to-id", cfg.Defaults.AssignedToID, "Assigned to user ID")
-	startDate := fs.String("start-date", "", "Start date (YYYY-MM-DD)")
-	dueDate := fs.String("due-date", "", "Due date (YYYY-MM-DD)")
-	var doneRatio optionalInt
-	fs.Var(&doneRatio, "done-ratio", "Done ratio (0-100)")
-	jsonOut := fs.Bool("json", false, "JSON output")
-
-	if err := fs.Parse(args); err != nil {
-		return 2
+	if globals.retryAttempts > 0 {
+		cfg.Retry.Attempts = globals.retryAttempts
 	}
-
-	if err := requireString("subject", *subject); err != nil {
-		return usageError(err)
+	if globals.rps > 0 {
+		cfg.RPS = globals.rps
 	}
-	if err := requireInt("project-id", *projectID); err != nil {
-		return usageError(err)
+	if globals.caFile != "" {
+		cfg.CAFile = globals.caFile
 	}
-	if err := requireInt("tracker-id", *trackerID); err != nil {
-		return usageError(err)
+	if globals.clientCert != "" {
+		cfg.ClientCert = globals.clientCert
 	}
-	if err := requireInt("status-id", *statusID); err != nil {
-		return usageError(err)
+	if globals.clientKey != "" {
+		cfg.ClientKey = globals.clientKey
 	}
-	if err := requireInt("priority-id", *priorityID); err != nil {
-		return usageError(err)
+	if globals.insecure {
+		cfg.InsecureSkipVerify = boolPtr(true)
 	}
-	if err := requireInt("author-id", *authorID); err != nil {
-		return usageError(err)
+	if globals.proxyURL != "" {
+		cfg.ProxyURL = globals.proxyURL
 	}
-	if err := requireInt("assigned-to-id", *assignedToID); err != nil {
-		return usageError(err)
+	if globals.requestTimeout > 0 {
+		cfg.RequestTimeout = int((globals.requestTimeout + time.Second - 1) / time.Second)
+	}
+	if globals.concurrency > 0 {
+		cfg.Concurrency = globals.concurrency
+	}
+	if globals.color != "" {
+		cfg.Color = globals.color
 	}
 
-	input := api.IssueInput{
-		Subject:      stringPtr(*subject),
-		ProjectID:    intPtr(*projectID),
-		TrackerID:    intPtr(*trackerID),
-		StatusID:     intPtr(*statusID),
-		PriorityID:   intPtr(*priorityID),
-		AuthorID:     intPtr(*authorID),
-		AssignedToID: intPtr(*assignedToID),
+	if err := checkFormat(globals.output); err != nil {
+		return inv.usageError(err)
 	}
-	if strings.TrimSpace(*description) != "" {
-		input.Description = stringPtr(*description)
+	if err := checkColorMode(cfg.Color); err != nil {
+		return inv.usageError(err)
 	}
-	if strings.TrimSpace(*startDate) != "" {
-		input.StartDate = stringPtr(*startDate)
+	if globals.recordDir != "" && globals.replayDir != "" {
+		return inv.usageError(fmt.Errorf("--record and --replay cannot be combined"))
 	}
-	if strings.TrimSpace(*dueDate) != "" {
-		input.DueDate = stringPtr(*dueDate)
+	if globals.recordDir != "" || globals.replayDir != "" {
+		globals.noCache = true
 	}
-	if doneRatio.set {
-		input.DoneRatio = intPtr(doneRatio.value)
+	if globals.replayDir != "" {
+		cfg.AuthMode = config.AuthModeAPIKey
+		if cfg.APIKey == "" {
+			cfg.APIKey = "replay"
+		}
 	}
 
-	resp, err := client.CreateIssue(context.Background(), input)
+	if globals.harPath != "" {
+		globals.har = &api.HARRecorder{}
+		defer func() {
+			if err := globals.har.WriteFile(globals.harPath); err != nil {
+				fmt.Fprintln(os.Stderr, "har error:", err)
+			}
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if globals.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, globals.timeout, errTimedOut)
+		defer cancel()
+	}
+	inv.ctx, inv.cfg = ctx, cfg
+	return action()
+}
+
+func newGlobalFlags(globals *globalOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("easy8", flag.ContinueOnError)
+	formats := strings.Join(formatNames, ", ")
+	fs.StringVar(&globals.profile, "profile", "", "Config `profile` (env EASY8_PROFILE)")
+	fs.StringVar(&globals.asUser, "as-user", "", "Act as another user via X-Redmine-Switch-User (`login` or full name)")
+	fs.IntVar(&globals.retryAttempts, "retry-attempts", 0, "Attempts for 429/502/503/504 and connection resets (1 disables retries, default 3)")
+	fs.Float64Var(&globals.rps, "rps", 0, "Maximum requests per second (token bucket)")
+	fs.IntVar(&globals.concurrency, "concurrency", 0, "Maximum requests in flight")
+	fs.BoolVar(&globals.verbose, "verbose", false, "Log method, URL, status and duration to stderr")
+	fs.BoolVar(&globals.trace, "trace", false, "Like --verbose, plus headers and JSON bodies (credentials redacted)")
+	fs.StringVar(&globals.harPath, "har", "", "Record all requests and responses to a HAR `file`")
+	fs.StringVar(&globals.caFile, "ca-file", "", "Trust an additional CA bundle (PEM `file`)")
+	fs.StringVar(&globals.clientCert, "client-cert", "", "Client certificate `file` for mutual TLS (with --client-key)")
+	fs.StringVar(&globals.clientKey, "client-key", "", "Client private key `file` for mutual TLS")
+	fs.BoolVar(&globals.insecure, "insecure", false, "Skip TLS certificate verification (unsafe, prints a warning)")
+	fs.StringVar(&globals.proxyURL, "proxy", "", "Proxy `URL` (default: HTTPS_PROXY/HTTP_PROXY)")
+	fs.DurationVar(&globals.requestTimeout, "request-timeout", 0, "Timeout per HTTP request (default 30s)")
+	fs.DurationVar(&globals.timeout, "timeout", 0, "Deadline for the whole command; exits 124 when exceeded")
+	fs.BoolVar(&globals.noCache, "no-cache", false, "Bypass the on-disk lookup cache")
+	fs.StringVar(&globals.recordDir, "record", "", "Save every request/response (credentials stripped) to `dir`")
+	fs.StringVar(&globals.replayDir, "replay", "", "Answer requests from a recording made with --record (offline) in `dir`")
+	fs.StringVar(&globals.output, "output", "table", "Output `format`: "+formats)
+	fs.StringVar(&globals.output, "o", "table", "Output `format`: "+formats)
+	fs.StringVar(&globals.template, "template", "", "Go `template` per item, e.g. '{{.ID}} {{.Subject}}', or a named config template")
+	fs.StringVar(&globals.templateFile, "template-file", "", "Read the --template text from a `file`")
+	fs.BoolVar(&globals.wide, "wide", false, "Do not truncate table columns to the terminal width")
+	fs.StringVar(&globals.query, "query", "", "JMESPath `expression` applied to the JSON output, e.g. 'issues[*].id'")
+	fs.StringVar(&globals.query, "jq", "", "JMESPath `expression` applied to the JSON output, e.g. 'issues[*].id'")
+	fs.StringVar(&globals.color, "color", "", "Color `mode`: auto (default: only on a terminal, off with NO_COLOR), always or never")
+	fs.BoolVar(&globals.noPager, "no-pager", false, "Do not pipe output taller than the terminal through $EASY8_PAGER/$PAGER")
+	return fs
+}
+
+// newAPIClient builds the low-level client from the config, for commands
+// that need more than the SDK exposes (OAuth login).
+func newAPIClient(cfg config.Config) (*api.Client, error) {
+	client, err := api.NewClient(cfg)
 	if err != nil {
-		return apiError(err)
+		return nil, err
 	}
+	warnInsecure(cfg)
+	return client, nil
+}
+
+func warnInsecure(cfg config.Config) {
+	if cfg.Insecure() {
+		fmt.Fprintln(os.Stderr, "WARNING: TLS certificate verification is disabled (insecure_skip_verify); connections can be intercepted.")
+	}
+}
 
-	if *jsonOut {
-		return outputJSON(resp)
+// newClient builds the SDK client with the profile's transport, auth, retry
+// and rate limit settings and the middleware selected by the global flags.
+func newClient(cfg config.Config, globals globalOptions) (*easy8.Client, error) {
+	opts := []easy8.Option{api.WithConfig(cfg)}
+	if globals.verbose || globals.trace {
+		opts = append(opts, easy8.WithMiddleware(easy8.Logging(os.Stderr, globals.trace)))
+	}
+	if globals.har != nil {
+		opts = append(opts, easy8.WithMiddleware(globals.har.Middleware()))
+	}
+	if globals.recordDir != "" {
+		recorder, err := api.NewRecorder(globals.recordDir)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, easy8.WithMiddleware(recorder.Middleware()))
+	}
+	if globals.replayDir != "" {
+		replayer, err := api.LoadReplayer(globals.replayDir)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, easy8.WithMiddleware(replayer.Middleware()))
+	}
+	client, err := easy8.New(cfg.BaseURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	warnInsecure(cfg)
+	return client, nil
+}
+
+func newLookups(cfg config.Config, globals globalOptions, client *easy8.Client) *cache.Lookups {
+	lookups := &cache.Lookups{Client: serviceLookups{client}, BaseURL: client.BaseURL(), Profile: cfg.Profile, Disabled: globals.noCache}
+	if dir, err := cache.Dir(client.BaseURL(), cfg.Profile); err == nil {
+		lookups.Store = &cache.Store{Dir: dir, TTL: time.Duration(cfg.CacheTTL) * time.Second}
+	}
+	return lookups
+}
+
+var issueCommand = &command{
+	name: "issue",
+	commands: []*command{
+		{
+			name: "create", summary: "Create a new issue", setup: setupIssueCreate,
+			examples: []string{
+				"easy8 issue create --subject \"Fix login\" --project-id 1 --tracker-id 1 --status-id 1 --priority-id 1 --author-id 1 --assigned-to-id 2",
+			},
+		},
+		{
+			name: "show", args: "<id>", minArgs: 1, maxArgs: 1, summary: "Show an issue", setup: setupIssueShow,
+			examples: []string{
+				"easy8 issue show 123",
+				"easy8 issue show 123 --include journals --json",
+			},
+		},
+		{
+			name: "list", summary: "List issues", setup: setupIssueList,
+			examples: []string{
+				"easy8 issue list --limit 10",
+				"easy8 issue list --all --parallel 4",
+				"easy8 issue list --output csv > issues.csv",
+				"easy8 issue list --columns id,subject,priority,due_date,project,cf:Customer",
+				"easy8 issue list --json id,subject,status.name",
+				"easy8 issue list --all --group-by assignee --sum",
+				"easy8 issue list --query \"issues[?status.name == 'New'].id\"",
+				"easy8 issue list --template '{{.ID}} {{.Subject | truncate 40}} {{nameOf .Status}}'",
+			},
+		},
+		{
+			name: "search", summary: "Fulltext search", setup: setupIssueSearch,
+			examples: []string{
+				"easy8 issue search --q \"onboarding\"",
+				"easy8 issue search --q \"petr\" --assignee-id 51 --status-id 2 --priority-id 3",
+				"easy8 issue search --q \"petr\" --assignee \"Alice Doe\" --status \"New\" --priority \"High\" --task-type \"Task\" --project \"Project A\"",
+				"easy8 issue search --status New --all --group-by status,assignee --summary",
+			},
+		},
+		{
+			name: "update", args: "<id>", maxArgs: 1, summary: "Update an issue", setup: setupIssueUpdate,
+			examples: []string{
+				"easy8 issue update 123 --status-id 5 --done-ratio 80",
+				"easy8 issue update 123 --status \"In Progress\" --assignee alice --notes \"Taking this\"",
+			},
+		},
+	},
+}
+
+// issueClient builds the client of the issue commands, acting as the
+// --as-user login when one is configured.
+func issueClient(inv *invocation) (*easy8.Client, lookupSource, int) {
+	ctx, cfg, globals := inv.ctx, inv.cfg, *inv.globals
+	client, err := newClient(cfg, globals)
+	if err != nil {
+		return nil, nil, inv.configError(err)
+	}
+	lookups := newLookups(cfg, globals, client)
+	if strings.TrimSpace(cfg.AsUser) != "" {
+		login, err := resolveSwitchUser(ctx, client, lookups, cfg.AsUser)
+		if err != nil {
+			return nil, nil, inv.lookupError(err)
+		}
+		client.ActAs(login)
 	}
-	return outputIssues([]api.Issue{resp.Issue})
+	return client, lookups, 0
 }
 
-func runIssueList(args []string, cfg config.Config, client *api.Client) int {
-	fs := flag.NewFlagSet("issue list", flag.ContinueOnError)
-	fs.SetOutput(os.Stderr)
+func setupIssueCreate(fs *flag.FlagSet, inv *invocation) func() int {
+	subject := fs.String("subject", "", "Issue subject (required)")
+	description := fs.String("description", "", "Issue description")
+	projectID := fs.Int("project-id", 0, "Project ID (default: defaults.project_id)")
+	trackerID := fs.Int("tracker-id", 0, "Tracker ID (default: defaults.tracker_id)")
+	statusID := fs.Int("status-id", 0, "Status ID (default: defaults.status_id)")
+	priorityID := fs.Int("priority-id", 0, "Priority ID (default: defaults.priority_id)")
+	authorID := fs.Int("author-id", 0, "Author ID (default: defaults.author_id)")
+	assignedToID := fs.Int("assigned-to-id", 0, "Assigned to user ID (default: defaults.assigned_to_id)")
+	startDate := fs.String("start-date", "", "Start date (YYYY-MM-DD)")
+	dueDate := fs.String("due-date", "", "Due date (YYYY-MM-DD)")
+	var doneRatio optionalInt
+	fs.Var(&doneRatio, "done-ratio", "Done ratio (0-100)")
+	idempotencyKey := fs.String("idempotency-key", "", "Allow retrying the create request; sent as Idempotency-Key")
+	out := addIssueOutputFlags(fs, inv)
+
+	return func() int {
+		ctx, cfg := inv.ctx, inv.cfg
+		output, err := out.printer(cfg)
+		if err != nil {
+			return inv.usageError(err)
+		}
 
+		defaultInt(projectID, cfg.Defaults.ProjectID)
+		defaultInt(trackerID, cfg.Defaults.TrackerID)
+		defaultInt(statusID, cfg.Defaults.StatusID)
+		defaultInt(priorityID, cfg.Defaults.PriorityID)
+		defaultInt(authorID, cfg.Defaults.AuthorID)
+		defaultInt(assignedToID, cfg.Defaults.AssignedToID)
+		if err := requireString("subject", *subject); err != nil {
+			return inv.usageError(err)
+		}
+		if err := requireInt("project-id", *projectID); err != nil {
+			return inv.usageError(err)
+		}
+		if err := requireInt("tracker-id", *trackerID); err != nil {
+			return inv.usageError(err)
+		}
+		if err := requireInt("status-id", *statusID); err != nil {
+			return inv.usageError(err)
+		}
+		if err := requireInt("priority-id", *priorityID); err != nil {
+			return inv.usageError(err)
+		}
+		if err := requireInt("author-id", *authorID); err != nil {
+			return inv.usageError(err)
+		}
+		if err := requireInt("assigned-to-id", *assignedToID); err != nil {
+			return inv.usageError(err)
+		}
+
+		input := api.IssueInput{
+			Subject:      stringPtr(*subject),
+			ProjectID:    intPtr(*projectID),
+			TrackerID:    intPtr(*trackerID),
+			StatusID:     intPtr(*statusID),
+			PriorityID:   intPtr(*priorityID),
+			AuthorID:     intPtr(*authorID),
+			AssignedToID: intPtr(*assignedToID),
+		}
+		if strings.TrimSpace(*description) != "" {
+			input.Description = stringPtr(*description)
+		}
+		if strings.TrimSpace(*startDate) != "" {
+			input.StartDate = stringPtr(*startDate)
+		}
+		if strings.TrimSpace(*dueDate) != "" {
+			input.DueDate = stringPtr(*dueDate)
+		}
+		if doneRatio.set {
+			input.DoneRatio = intPtr(doneRatio.value)
+		}
+
+		client, lookups, code := issueClient(inv)
+		if code != 0 {
+			return code
+		}
+		if strings.TrimSpace(*idempotencyKey) != "" {
+			ctx = easy8.WithIdempotencyKey(ctx, strings.TrimSpace(*idempotencyKey))
+		}
+		issue, err := client.Issues.Create(ctx, input)
+		if err != nil {
+			return inv.apiError(err)
+		}
+
+		output.loadStyles(ctx, lookups)
+		return output.print(output.issueDocument(api.IssueResponse{Issue: issue}, []api.Issue{issue}))
+	}
+}
+
+func setupIssueList(fs *flag.FlagSet, inv *invocation) func() int {
 	limit := fs.Int("limit", 25, "Limit (max 100)")
 	offset := fs.Int("offset", 0, "Offset")
 	sort := fs.String("sort", "", "Sort expression")
 	query := fs.String("q", "", "Free-text query (easy_query_q)")
 	include := fs.String("include", "", "Include fields (comma-separated)")
-	jsonOut := fs.Bool("json", false, "JSON output")
-
-	if err := fs.Parse(args); err != nil {
-		return 2
-	}
+	paging := addPagingFlags(fs)
+	out := addIssueOutputFlags(fs, inv)
+	addGroupFlags(fs, out)
+
+	return func() int {
+		ctx, cfg := inv.ctx, inv.cfg
+		output, err := out.printer(cfg)
+		if err != nil {
+			return inv.usageError(err)
+		}
 
-	params := api.IssueListParams{
-		Limit:  *limit,
-		Offset: *offset,
-		Sort:   strings.TrimSpace(*sort),
-		Query:  strings.TrimSpace(*query),
-	}
-	if strings.TrimSpace(*include) != "" {
-		params.Include = splitComma(*include)
-	}
+		params := api.IssueListParams{
+			Limit:  *limit,
+			Offset: *offset,
+			Sort:   strings.TrimSpace(*sort),
+			Query:  strings.TrimSpace(*query),
+		}
+		if strings.TrimSpace(*include) != "" {
+			params.Include = splitComma(*include)
+		}
 
-	resp, err := client.ListIssues(context.Background(), params)
-	if err != nil {
-		return apiError(err)
-	}
-	if *jsonOut {
-		return outputJSON(resp)
+		client, lookups, code := issueClient(inv)
+		if code != 0 {
+			return code
+		}
+		resp, err := fetchIssues(ctx, client, params, paging)
+		output.loadStyles(ctx, lookups)
+		return finishIssueList(inv, resp, err, output)
 	}
-	return outputIssues(resp.Issues)
 }
 
-func runIssueSearch(args []string, cfg config.Config, client *api.Client) int {
-	fs := flag.NewFlagSet("issue search", flag.ContinueOnError)
-	fs.SetOutput(os.Stderr)
-
+func setupIssueSearch(fs *flag.FlagSet, inv *invocation) func() int {
 	query := fs.String("q", "", "Search query")
 	limit := fs.Int("limit", 25, "Limit (max 100)")
 	offset := fs.Int("offset", 0, "Offset")
 	sort := fs.String("sort", "", "Sort expression")
 	include := fs.String("include", "", "Include fields (comma-separated)")
-	var assigneeID optionalInt
-	var statusID optionalInt
-	var priorityID optionalInt
-	var taskTypeID optionalInt
-	var projectID optionalInt
-	fs.Var(&assigneeID, "assignee-id", "Assignee user ID")
-	fs.Var(&statusID, "status-id", "Status ID")
-	fs.Var(&priorityID, "priority-id", "Priority ID")
-	fs.Var(&taskTypeID, "task-type-id", "Task type (tracker) ID")
-	fs.Var(&projectID, "project-id", "Project ID")
+	var filters searchFilters
+	fs.Var(&filters.assigneeID, "assignee-id", "Assignee user ID")
+	fs.Var(&filters.statusID, "status-id", "Status ID")
+	fs.Var(&filters.priorityID, "priority-id", "Priority ID")
+	fs.Var(&filters.taskTypeID, "task-type-id", "Task type (tracker) ID")
+	fs.Var(&filters.projectID, "project-id", "Project ID")
 	var dueDate string
 	var subject string
-	var assignee string
-	var status string
-	var priority string
-	var taskType string
-	var project string
 	fs.StringVar(&dueDate, "due-date", "", "Due date (YYYY-MM-DD)")
 	fs.StringVar(&subject, "subject", "", "Subject filter")
-	fs.StringVar(&assignee, "assignee", "", "Assignee login or name")
-	fs.StringVar(&status, "status", "", "Status name")
-	fs.StringVar(&priority, "priority", "", "Priority name")
-	fs.StringVar(&taskType, "task-type", "", "Task type (tracker) name")
-	fs.StringVar(&project, "project", "", "Project name")
-	jsonOut := fs.Bool("json", false, "JSON output")
+	fs.StringVar(&filters.assignee, "assignee", "", "Assignee login or name")
+	fs.StringVar(&filters.status, "status", "", "Status name")
+	fs.StringVar(&filters.priority, "priority", "", "Priority name")
+	fs.StringVar(&filters.taskType, "task-type", "", "Task type (tracker) name")
+	fs.StringVar(&filters.project, "project", "", "Project name")
+	paging := addPagingFlags(fs)
+	out := addIssueOutputFlags(fs, inv)
+	addGroupFlags(fs, out)
+
+	return func() int {
+		ctx, cfg := inv.ctx, inv.cfg
+		output, err := out.printer(cfg)
+		if err != nil {
+			return inv.usageError(err)
+		}
 
-	if err := fs.Parse(args); err != nil {
-		return 2
-	}
+		client, lookups, code := issueClient(inv)
+		if code != 0 {
+			return code
+		}
+		resolved, err := resolveSearchFilters(ctx, client, lookups, filters)
+		if err != nil {
+			return inv.lookupError(err)
+		}
+		for _, line := range resolved.notes {
+			inv.note(line)
+		}
 
-	resolvedAssigneeID, err := resolveAssigneeID(context.Background(), client, assigneeID, assignee)
-	if err != nil {
-		return usageError(err)
-	}
-	resolvedStatusID, err := resolveStatusID(context.Background(), client, statusID, status)
-	if err != nil {
-		return usageError(err)
-	}
-	resolvedPriorityID, err := resolvePriorityID(context.Background(), client, priorityID, priority)
-	if err != nil {
-		return usageError(err)
-	}
-	resolvedTaskTypeID, err := resolveTaskTypeID(context.Background(), client, taskTypeID, taskType)
-	if err != nil {
-		return usageError(err)
-	}
-	resolvedProjectID, err := resolveProjectID(context.Background(), client, projectID, project)
-	if err != nil {
-		return usageError(err)
+		queryValue := strings.TrimSpace(*query)
+		if queryValue == "" && resolved.assigneeID == 0 && resolved.statusID == 0 && resolved.priorityID == 0 && resolved.taskTypeID == 0 && resolved.projectID == 0 && strings.TrimSpace(dueDate) == "" && strings.TrimSpace(subject) == "" {
+			return inv.usageError(fmt.Errorf("at least one filter is required (e.g. --q, --status, --assignee)"))
+		}
+
+		params := api.IssueListParams{
+			Limit:      *limit,
+			Offset:     *offset,
+			Sort:       strings.TrimSpace(*sort),
+			Query:      queryValue,
+			DueDate:    strings.TrimSpace(dueDate),
+			Subject:    strings.TrimSpace(subject),
+			AssigneeID: resolved.assigneeID,
+			StatusID:   resolved.statusID,
+			PriorityID: resolved.priorityID,
+			TaskTypeID: resolved.taskTypeID,
+			ProjectID:  resolved.projectID,
+		}
+		if strings.TrimSpace(*include) != "" {
+			params.Include = splitComma(*include)
+		}
+
+		resp, err := fetchIssues(ctx, client, params, paging)
+		output.loadStyles(ctx, lookups)
+		return finishIssueList(inv, resp, err, output)
 	}
+}
 
-	queryValue := strings.TrimSpace(*query)
-	if queryValue == "" && resolvedAssigneeID == 0 && resolvedStatusID == 0 && resolvedPriorityID == 0 && resolvedTaskTypeID == 0 && resolvedProjectID == 0 && strings.TrimSpace(dueDate) == "" && strings.TrimSpace(subject) == "" {
-		return usageError(fmt.Errorf("at least one filter is required (e.g. --q, --status, --assignee)"))
+type pagingFlags struct {
+	all      bool
+	pageSize int
+	parallel int
+}
+
+func addPagingFlags(fs *flag.FlagSet) *pagingFlags {
+	paging := &pagingFlags{}
+	fs.BoolVar(&paging.all, "all", false, "Fetch every page (ignores --limit)")
+	fs.IntVar(&paging.pageSize, "page-size", api.DefaultPageSize, "Page size with --all")
+	fs.IntVar(&paging.parallel, "parallel", 1, "Pages fetched in parallel with --all")
+	return paging
+}
+
+// fetchIssues fetches one page, or every page with --all.
+func fetchIssues(ctx context.Context, client *easy8.Client, params api.IssueListParams, paging *pagingFlags) (api.IssueListResponse, error) {
+	if !paging.all {
+		return client.Issues.List(ctx, params)
 	}
+	resp := api.IssueListResponse{Offset: params.Offset}
+	opts := api.PageOptions{PageSize: paging.pageSize, Offset: params.Offset, Prefetch: paging.parallel}
+	total, err := client.Issues.Each(ctx, params, opts, func(issue api.Issue) error {
+		resp.Issues = append(resp.Issues, issue)
+		return nil
+	})
+	resp.TotalCount = total
+	resp.Limit = len(resp.Issues)
+	return resp, err
+}
 
-	params := api.IssueListParams{
-		Limit:      *limit,
-		Offset:     *offset,
-		Sort:       strings.TrimSpace(*sort),
-		Query:      queryValue,
-		DueDate:    strings.TrimSpace(dueDate),
-		Subject:    strings.TrimSpace(subject),
-		AssigneeID: resolvedAssigneeID,
-		StatusID:   resolvedStatusID,
-		PriorityID: resolvedPriorityID,
-		TaskTypeID: resolvedTaskTypeID,
-		ProjectID:  resolvedProjectID,
+// finishIssueList prints the issues fetched so far when paging was
+// interrupted, followed by a progress note. Groups of one page get a note
+// that they do not cover every matching issue.
+func finishIssueList(inv *invocation, resp api.IssueListResponse, err error, output printer) int {
+	if err != nil && (!inv.interrupted(err) || len(resp.Issues) == 0) {
+		return inv.apiError(err)
 	}
-	if strings.TrimSpace(*include) != "" {
-		params.Include = splitComma(*include)
+	if err == nil && output.group != nil && len(resp.Issues) < resp.TotalCount {
+		inv.note(fmt.Sprintf("grouped %d of %d issues (use --all to group every issue)", len(resp.Issues), resp.TotalCount))
 	}
-
-	resp, err := client.ListIssues(context.Background(), params)
+	code := output.print(output.issueDocument(resp, resp.Issues))
 	if err != nil {
-		return apiError(err)
+		fmt.Fprintf(os.Stderr, "fetched %d of %d issues\n", len(resp.Issues), resp.TotalCount)
+		return inv.apiError(err)
 	}
-	if *jsonOut {
-		return outputJSON(resp)
-	}
-	return outputIssues(resp.Issues)
+	return code
 }
 
-func runIssueUpdate(args []string, cfg config.Config, client *api.Client) int {
-	fs := flag.NewFlagSet("issue update", flag.ContinueOnError)
-	fs.SetOutput(os.Stderr)
-
-	id := fs.Int("id", 0, "Issue ID (required)")
+func setupIssueUpdate(fs *flag.FlagSet, inv *invocation) func() int {
+	id := fs.Int("id", 0, "Issue ID (instead of the <id> argument)")
 	subject := fs.String("subject", "", "Issue subject")
 	description := fs.String("description", "", "Issue description")
 	var statusID optionalInt
@@ -284,55 +581,131 @@ func runIssueUpdate(args []string, cfg config.Config, client *api.Client) int {
 	fs.Var(&priorityID, "priority-id", "Priority ID")
 	fs.Var(&assignedToID, "assigned-to-id", "Assigned to user ID")
 	fs.Var(&doneRatio, "done-ratio", "Done ratio (0-100)")
+	status := fs.String("status", "", "Status name")
+	priority := fs.String("priority", "", "Priority name")
+	assignee := fs.String("assignee", "", "Assignee login or name")
 	notes := fs.String("notes", "", "Notes (journal entry)")
-	jsonOut := fs.Bool("json", false, "JSON output")
+	out := addIssueOutputFlags(fs, inv)
 
-	if err := fs.Parse(args); err != nil {
-		return 2
-	}
+	return func() int {
+		ctx, cfg := inv.ctx, inv.cfg
+		output, err := out.printer(cfg)
+		if err != nil {
+			return inv.usageError(err)
+		}
 
-	if err := requireInt("id", *id); err != nil {
-		return usageError(err)
-	}
+		issueID, err := issueIDArg(inv.args, *id)
+		if err != nil {
+			return inv.usageError(err)
+		}
 
-	input := api.IssueInput{}
-	if strings.TrimSpace(*subject) != "" {
-		input.Subject = stringPtr(*subject)
-	}
-	if strings.TrimSpace(*description) != "" {
-		input.Description = stringPtr(*description)
-	}
-	if statusID.set {
-		input.StatusID = intPtr(statusID.value)
+		input := api.IssueInput{}
+		if strings.TrimSpace(*subject) != "" {
+			input.Subject = stringPtr(*subject)
+		}
+		if strings.TrimSpace(*description) != "" {
+			input.Description = stringPtr(*description)
+		}
+		if doneRatio.set {
+			input.DoneRatio = intPtr(doneRatio.value)
+		}
+		if strings.TrimSpace(*notes) != "" {
+			input.Notes = stringPtr(*notes)
+		}
+
+		client, lookups, code := issueClient(inv)
+		if code != 0 {
+			return code
+		}
+		filters := searchFilters{statusID: statusID, priorityID: priorityID, assigneeID: assignedToID, status: *status, priority: *priority, assignee: *assignee}
+		resolved, err := resolveSearchFilters(ctx, client, lookups, filters)
+		if err != nil {
+			return inv.lookupError(err)
+		}
+		for _, line := range resolved.notes {
+			inv.note(line)
+		}
+		if statusID.set || strings.TrimSpace(*status) != "" {
+			input.StatusID = intPtr(resolved.statusID)
+		}
+		if priorityID.set || strings.TrimSpace(*priority) != "" {
+			input.PriorityID = intPtr(resolved.priorityID)
+		}
+		if assignedToID.set || strings.TrimSpace(*assignee) != "" {
+			input.AssignedToID = intPtr(resolved.assigneeID)
+		}
+		issue, err := client.Issues.Update(ctx, issueID, input)
+		if err != nil {
+			return inv.apiError(err)
+		}
+		output.loadStyles(ctx, lookups)
+		return output.print(output.issueDocument(api.IssueResponse{Issue: issue}, []api.Issue{issue}))
 	}
-	if priorityID.set {
-		input.PriorityID = intPtr(priorityID.value)
+}
+
+func setupIssueShow(fs *flag.FlagSet, inv *invocation) func() int {
+	include := fs.String("include", "", "Include associations, e.g. journals,attachments (comma-separated)")
+	out := addIssueOutputFlags(fs, inv)
+
+	return func() int {
+		ctx, cfg := inv.ctx, inv.cfg
+		output, err := out.printer(cfg)
+		if err != nil {
+			return inv.usageError(err)
+		}
+		issueID, err := issueIDArg(inv.args, 0)
+		if err != nil {
+			return inv.usageError(err)
+		}
+
+		client, lookups, code := issueClient(inv)
+		if code != 0 {
+			return code
+		}
+		issue, err := client.Issues.Get(ctx, issueID, splitComma(*include)...)
+		if err != nil {
+			return inv.apiError(err)
+		}
+		output.loadStyles(ctx, lookups)
+		return output.print(output.issueDocument(api.IssueResponse{Issue: issue}, []api.Issue{issue}))
 	}
-	if assignedToID.set {
-		input.AssignedToID = intPtr(assignedToID.value)
+}
+
+// issueIDArg is the issue ID from the <id> argument ("123" or "#123") or,
+// for compatibility, the --id flag.
+func issueIDArg(args []string, flagValue int) (int, error) {
+	if len(args) == 0 {
+		if flagValue == 0 {
+			return 0, fmt.Errorf("an issue ID is required")
+		}
+		return flagValue, nil
 	}
-	if doneRatio.set {
-		input.DoneRatio = intPtr(doneRatio.value)
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid issue ID %q", args[0])
 	}
-	if strings.TrimSpace(*notes) != "" {
-		input.Notes = stringPtr(*notes)
+	if flagValue != 0 && flagValue != id {
+		return 0, fmt.Errorf("issue ID given twice: %d and --id %d", id, flagValue)
 	}
+	return id, nil
+}
 
-	resp, err := client.UpdateIssue(context.Background(), *id, input)
-	if err != nil {
-		return apiError(err)
-	}
-	if *jsonOut {
-		return outputJSON(resp)
+func (inv *invocation) usageError(err error) int {
+	if err != nil && inv.jsonErrors {
+		writeErrorJSON(errorKindUsage, err.Error(), nil)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
 	}
-	return outputIssues([]api.Issue{resp.Issue})
+	return exitUsage
 }
 
-func usageError(err error) int {
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "error:", err)
+// lookupError reports a failed name lookup: a bad name is a usage error,
+// a failed request (e.g. 401 or 5xx) is reported like any other.
+func (inv *invocation) lookupError(err error) int {
+	if badName(err) && !inv.interrupted(err) {
+		return inv.usageError(err)
 	}
-	return 2
+	return inv.apiError(err)
 }
 
 func requireString(name, value string) error {
@@ -342,6 +715,13 @@ func requireString(name, value string) error {
 	return nil
 }
 
+// defaultInt fills an unset ID flag from the config defaults.
+func defaultInt(value *int, fallback int) {
+	if *value == 0 {
+		*value = fallback
+	}
+}
+
 func requireInt(name string, value int) error {
 	if value == 0 {
 		return fmt.Errorf("--%s is required", name)
@@ -349,49 +729,6 @@ func requireInt(name string, value int) error {
 	return nil
 }
 
-func printUsage() {
-	lines := []string{
-		"easy8-cli",
-		"",
-		"Usage:",
-		"  easy8 issue <command> [flags]",
-		"",
-		"Commands:",
-		"  issue create   Create a new issue",
-		"  issue list     List issues",
-		"  issue search   Fulltext search",
-		"  issue update   Update an issue",
-		"",
-		"Use 'easy8 issue --help' for details.",
-	}
-	for _, line := range lines {
-		fmt.Fprintln(os.Stderr, line)
-	}
-}
-
-func printIssueUsage() {
-	lines := []string{
-		"easy8 issue",
-		"",
-		"Usage:",
-		"  easy8 issue create [flags]",
-		"  easy8 issue list [flags]",
-		"  easy8 issue search [flags]",
-		"  easy8 issue update [flags]",
-		"",
-		"Examples:",
-		"  easy8 issue list --limit 10",
-		"  easy8 issue search --q \"onboarding\"",
-		"  easy8 issue search --q \"petr\" --assignee-id 51 --status-id 2 --priority-id 3",
-		"  easy8 issue search --q \"petr\" --assignee \"Alice Doe\" --status \"New\" --priority \"High\" --task-type \"Task\" --project \"Project A\"",
-		"  easy8 issue create --subject \"Fix login\" --project-id 1 --tracker-id 1 --status-id 1 --priority-id 1 --author-id 1 --assigned-to-id 2",
-		"  easy8 issue update --id 123 --status-id 5",
-	}
-	for _, line := range lines {
-		fmt.Fprintln(os.Stderr, line)
-	}
-}
-
 type optionalInt struct {
 	set   bool
 	value int
@@ -443,177 +780,95 @@ func intPtr(value int) *int {
 	return &value
 }
 
-func apiError(err error) int {
-	var apiErr api.APIError
-	if errors.As(err, &apiErr) {
-		fmt.Fprintf(os.Stderr, "api error %d: %s\n", apiErr.StatusCode, apiErr.Body)
-		return 1
-	}
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "error:", err)
-	}
-	return 1
-}
-
-type nameID struct {
-	ID   int
-	Name string
+func boolPtr(value bool) *bool {
+	return &value
 }
 
-func resolveAssigneeID(ctx context.Context, client *api.Client, id optionalInt, name string) (int, error) {
-	if strings.TrimSpace(name) == "" {
-		if id.set {
-			return id.value, nil
-		}
-		return 0, nil
-	}
+const (
+	exitError      = 1
+	exitUsage      = 2
+	exitAuth       = 3
+	exitForbidden  = 4
+	exitNotFound   = 5
+	exitValidation = 6
+	exitConflict   = 7
+	exitRateLimit  = 8
+	exitServer     = 9
 
-	users, err := client.ListUsers(ctx)
-	if err != nil {
-		return 0, err
-	}
-
-	needle := normalizeName(name)
-	var matches []api.User
-	for _, user := range users {
-		if matchesUser(user, needle) {
-			matches = append(matches, user)
-		}
-	}
-
-	if len(matches) == 0 {
-		return 0, fmt.Errorf("assignee not found: %s", name)
-	}
-	if len(matches) > 1 {
-		return 0, fmt.Errorf("assignee matches multiple users: %s", name)
-	}
-	match := matches[0]
-	if id.set && id.value != match.ID {
-		return 0, fmt.Errorf("assignee-id does not match assignee name")
-	}
-	return match.ID, nil
-}
-
-func resolveStatusID(ctx context.Context, client *api.Client, id optionalInt, name string) (int, error) {
-	if strings.TrimSpace(name) == "" {
-		if id.set {
-			return id.value, nil
-		}
-		return 0, nil
-	}
-	items, err := client.ListIssueStatuses(ctx)
-	if err != nil {
-		return 0, err
-	}
-	return resolveNameID(id, name, toNameIDsStatus(items), "status")
-}
+	exitTimeout     = 124
+	exitInterrupted = 130
+)
 
-func resolvePriorityID(ctx context.Context, client *api.Client, id optionalInt, name string) (int, error) {
-	if strings.TrimSpace(name) == "" {
-		if id.set {
-			return id.value, nil
+var errTimedOut = errors.New("timed out (--timeout)")
+
+func (inv *invocation) apiError(err error) int {
+	if inv.interrupted(err) {
+		if errors.Is(context.Cause(inv.ctx), errTimedOut) {
+			if inv.jsonErrors {
+				writeErrorJSON(errorKindTimeout, errTimedOut.Error(), nil)
+			} else {
+				fmt.Fprintln(os.Stderr, "error:", errTimedOut)
+			}
+			return exitTimeout
 		}
-		return 0, nil
-	}
-	items, err := client.ListIssuePriorities(ctx)
-	if err != nil {
-		return 0, err
-	}
-	return resolveNameID(id, name, toNameIDsPriority(items), "priority")
-}
-
-func resolveTaskTypeID(ctx context.Context, client *api.Client, id optionalInt, name string) (int, error) {
-	if strings.TrimSpace(name) == "" {
-		if id.set {
-			return id.value, nil
+		if inv.jsonErrors {
+			writeErrorJSON(errorKindInterrupted, "interrupted", nil)
+		} else {
+			fmt.Fprintln(os.Stderr, "interrupted")
 		}
-		return 0, nil
+		return exitInterrupted
 	}
-	items, err := client.ListTrackers(ctx)
-	if err != nil {
-		return 0, err
-	}
-	return resolveNameID(id, name, toNameIDsTracker(items), "task-type")
-}
 
-func resolveProjectID(ctx context.Context, client *api.Client, id optionalInt, name string) (int, error) {
-	if strings.TrimSpace(name) == "" {
-		if id.set {
-			return id.value, nil
+	var apiErr api.APIError
+	if !errors.As(err, &apiErr) {
+		if err != nil && inv.jsonErrors {
+			writeErrorJSON(errorKindOther, err.Error(), nil)
+		} else if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
 		}
-		return 0, nil
+		return exitError
 	}
-	items, err := client.ListProjects(ctx)
-	if err != nil {
-		return 0, err
-	}
-	return resolveNameID(id, name, toNameIDsProject(items), "project")
-}
 
-func resolveNameID(id optionalInt, name string, items []nameID, label string) (int, error) {
-	needle := normalizeName(name)
-	var matches []nameID
-	for _, item := range items {
-		if normalizeName(item.Name) == needle {
-			matches = append(matches, item)
+	if inv.jsonErrors {
+		writeErrorJSON(string(apiErr.Kind()), err.Error(), &apiErr)
+	} else if len(apiErr.Errors) > 0 {
+		fmt.Fprintf(os.Stderr, "api error %d (%s):\n", apiErr.StatusCode, apiErr.Kind())
+		for _, message := range apiErr.Errors {
+			fmt.Fprintln(os.Stderr, "  -", message)
 		}
+	} else if _, direct := err.(api.APIError); direct {
+		fmt.Fprintf(os.Stderr, "api error %d: %s\n", apiErr.StatusCode, apiErr.Body)
+	} else {
+		// A wrapped error keeps the context added by the caller.
+		fmt.Fprintln(os.Stderr, "error:", err)
 	}
-	if len(matches) == 0 {
-		return 0, fmt.Errorf("%s not found: %s", label, name)
-	}
-	if len(matches) > 1 {
-		return 0, fmt.Errorf("%s matches multiple entries: %s", label, name)
-	}
-	match := matches[0]
-	if id.set && id.value != match.ID {
-		return 0, fmt.Errorf("%s-id does not match %s name", label, label)
-	}
-	return match.ID, nil
-}
-
-func normalizeName(value string) string {
-	return strings.ToLower(strings.TrimSpace(value))
-}
-
-func matchesUser(user api.User, needle string) bool {
-	if normalizeName(user.Login) == needle {
-		return true
-	}
-	full := strings.TrimSpace(user.Firstname + " " + user.Lastname)
-	if normalizeName(full) == needle {
-		return true
-	}
-	return false
-}
-
-func toNameIDsStatus(items []api.IssueStatus) []nameID {
-	result := make([]nameID, 0, len(items))
-	for _, item := range items {
-		result = append(result, nameID{ID: item.ID, Name: item.Name})
-	}
-	return result
-}
-
-func toNameIDsPriority(items []api.IssuePriority) []nameID {
-	result := make([]nameID, 0, len(items))
-	for _, item := range items {
-		result = append(result, nameID{ID: item.ID, Name: item.Name})
-	}
-	return result
-}
-
-func toNameIDsTracker(items []api.Tracker) []nameID {
-	result := make([]nameID, 0, len(items))
-	for _, item := range items {
-		result = append(result, nameID{ID: item.ID, Name: item.Name})
-	}
-	return result
-}
-
-func toNameIDsProject(items []api.Project) []nameID {
-	result := make([]nameID, 0, len(items))
-	for _, item := range items {
-		result = append(result, nameID{ID: item.ID, Name: item.Name})
-	}
-	return result
+	return exitCode(apiErr.Kind())
+}
+
+// interrupted reports whether err ended the command because it was
+// interrupted or ran out of --timeout. Only the state of the command's
+// context (inv.ctx) tells these apart from a single request timing out
+// (--request-timeout), which is an ordinary error.
+func (inv *invocation) interrupted(err error) bool {
+	return err != nil && inv.ctx != nil && inv.ctx.Err() != nil
+}
+
+func exitCode(kind api.ErrorKind) int {
+	switch kind {
+	case api.ErrorKindAuth:
+		return exitAuth
+	case api.ErrorKindForbidden:
+		return exitForbidden
+	case api.ErrorKindNotFound:
+		return exitNotFound
+	case api.ErrorKindValidation:
+		return exitValidation
+	case api.ErrorKindConflict:
+		return exitConflict
+	case api.ErrorKindRateLimit:
+		return exitRateLimit
+	case api.ErrorKindServer:
+		return exitServer
+	}
+	return exitError
 }
@@ -3,13 +3,19 @@ package cli
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
-	"easy8-cli/internal/api"
+	"github.com/Easy8Com/easy8-cli/internal/api"
+	"github.com/Easy8Com/easy8-cli/pkg/easy8/easy8test"
 )
 
 func TestRunNoArgs(t *testing.T) {
@@ -130,7 +136,7 @@ func TestIssueListAPIError(t *testing.T) {
 	setTestEnv(t, server.URL)
 
 	stdout, stderr, code := captureRun(t, []string{"issue", "list"})
-	if code != 1 {
+	if code != exitServer {
 		t.Fatalf("code = %d stdout=%s", code, stdout)
 	}
 	if !strings.Contains(stderr, "api error 500") {
@@ -138,6 +144,297 @@ func TestIssueListAPIError(t *testing.T) {
 	}
 }
 
+func TestIssueListAll(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/issues.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			_, _ = w.Write([]byte("{\"issues\":[{\"id\":1,\"subject\":\"First\"},{\"id\":2,\"subject\":\"Second\"}],\"total_count\":3,\"offset\":0,\"limit\":2}"))
+		case "2":
+			_, _ = w.Write([]byte("{\"issues\":[{\"id\":3,\"subject\":\"Third\"}],\"total_count\":3,\"offset\":2,\"limit\":2}"))
+		default:
+			t.Errorf("unexpected offset: %s", r.URL.RawQuery)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	stdout, stderr, code := captureRun(t, []string{"issue", "list", "--all", "--parallel", "2", "--json"})
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	var resp api.IssueListResponse
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("json error: %v", err)
+	}
+	if len(resp.Issues) != 3 || resp.TotalCount != 3 || resp.Issues[2].Subject != "Third" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := newTestServer(t)
+	setTestEnv(t, server.URL)
+	dir := filepath.Join(t.TempDir(), "bug-123")
+
+	recorded, stderr, code := captureRun(t, []string{"--record", dir, "issue", "list"})
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	server.Close()
+
+	replayed, stderr, code := captureRun(t, []string{"--replay", dir, "issue", "list"})
+	if code != 0 || replayed != recorded {
+		t.Fatalf("code = %d stdout=%s stderr=%s", code, replayed, stderr)
+	}
+
+	_, stderr, code = captureRun(t, []string{"--replay", dir, "issue", "list", "--limit", "5"})
+	if code != exitError || !strings.Contains(stderr, "replay: no recorded interaction for GET /issues.json?limit=5") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestVerboseAndHAR(t *testing.T) {
+	server := newTestServer(t)
+	setTestEnv(t, server.URL)
+	harPath := filepath.Join(t.TempDir(), "session.har")
+
+	stdout, stderr, code := captureRun(t, []string{"--verbose", "--har", harPath, "issue", "list"})
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	if !strings.Contains(stdout, "Fix onboarding") {
+		t.Fatalf("unexpected stdout: %s", stdout)
+	}
+	if !strings.Contains(stderr, "> GET "+server.URL+"/issues.json") || !strings.Contains(stderr, "< 200 OK") {
+		t.Fatalf("unexpected stderr: %s", stderr)
+	}
+	data, err := os.ReadFile(harPath)
+	if err != nil {
+		t.Fatalf("read har: %v", err)
+	}
+	if !strings.Contains(string(data), "\"entries\"") || strings.Contains(string(data), "test-key") {
+		t.Fatalf("unexpected har: %s", data)
+	}
+}
+
+func TestInsecureWarnsLoudly(t *testing.T) {
+	server := newTestServer(t)
+	setTestEnv(t, server.URL)
+
+	_, stderr, code := captureRun(t, []string{"--insecure", "issue", "list"})
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	if !strings.Contains(stderr, "WARNING: TLS certificate verification is disabled") {
+		t.Fatalf("unexpected stderr: %s", stderr)
+	}
+
+	_, stderr, code = captureRun(t, []string{"--client-cert", "missing.pem", "issue", "list"})
+	if code != 1 || !strings.Contains(stderr, "client_cert and client_key must be set together") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestTimeoutExitCode(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/issues.json", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	_, stderr, code := captureRun(t, []string{"--timeout", "50ms", "issue", "list"})
+	if code != exitTimeout || !strings.Contains(stderr, "timed out") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestRequestTimeoutIsNotOverallTimeout(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/issues.json", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	_, stderr, code := captureRun(t, []string{"--request-timeout", "1ms", "--retry-attempts", "1", "issue", "list", "-o", "json"})
+	if code != exitError || !strings.Contains(stderr, `"kind":"other"`) || strings.Contains(stderr, "--timeout") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestInterruptReportsPartialProgress(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"users\":[{\"id\":11,\"login\":\"alice\"}],\"total_count\":1,\"offset\":0,\"limit\":100}"))
+	})
+	handler.HandleFunc("/projects.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"projects\":[{\"id\":5,\"name\":\"Project A\"}],\"total_count\":1,\"offset\":0,\"limit\":100}"))
+	})
+	handler.HandleFunc("/issue_statuses.json", func(w http.ResponseWriter, r *http.Request) {
+		process, err := os.FindProcess(os.Getpid())
+		if err == nil {
+			err = process.Signal(os.Interrupt)
+		}
+		if err != nil {
+			t.Errorf("signal: %v", err)
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	stdout, stderr, code := captureRun(t, []string{"cache", "refresh"})
+	if code != exitInterrupted {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	if !strings.Contains(stdout, "projects") || strings.Contains(stdout, "statuses") {
+		t.Fatalf("unexpected stdout: %s", stdout)
+	}
+	if !strings.Contains(stderr, "refreshed 2 of 5 tables") || !strings.Contains(stderr, "interrupted") {
+		t.Fatalf("unexpected stderr: %s", stderr)
+	}
+}
+
+func TestIssueUpdateValidationErrors(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/issues/101.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte("{\"errors\":[\"Subject cannot be blank\",\"Due date is not a valid date\"]}"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	_, stderr, code := captureRun(t, []string{"issue", "update", "--id", "101", "--subject", " x"})
+	if code != exitValidation {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	if !strings.Contains(stderr, "api error 422 (validation):\n  - Subject cannot be blank\n  - Due date is not a valid date\n") {
+		t.Fatalf("unexpected stderr: %q", stderr)
+	}
+
+	_, stderr, code = captureRun(t, []string{"issue", "update", "--id", "102", "--status-id", "2"})
+	if code != exitNotFound {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/issues/101.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte("{\"errors\":[\"Subject cannot be blank\",\"Due date is not a valid date\"]}"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	cases := []struct {
+		args []string
+		code int
+		want string
+	}{
+		{
+			[]string{"--output", "json", "issue", "update", "--id", "101", "--subject", " x"}, exitValidation,
+			`{"error":{"kind":"validation","message":"api error 422: Subject cannot be blank; Due date is not a valid date","status_code":422,"url":"` + server.URL + `/issues/101.json","validation_errors":["Subject cannot be blank","Due date is not a valid date"]}}`,
+		},
+		{
+			[]string{"issue", "update", "--id", "102", "--status-id", "2", "--json"}, exitNotFound,
+			`{"error":{"kind":"not_found","message":"api error 404: 404 page not found","status_code":404,"url":"` + server.URL + `/issues/102.json","validation_errors":[]}}`,
+		},
+		{
+			[]string{"-o", "json", "issue", "close"}, exitUsage,
+			`{"error":{"kind":"usage","message":"unknown issue command: close","status_code":null,"url":null,"validation_errors":[]}}`,
+		},
+		{
+			[]string{"issue", "list", "-o", "jsonl", "--columns", "nope"}, exitUsage,
+			`{"error":{"kind":"usage","message":"unknown column \"nope\" (use assignee, author, created, description, done_ratio, due_date, estimated_hours, id, priority, project, spent_hours, start_date, status, subject, tracker, updated or cf:<name>)","status_code":null,"url":null,"validation_errors":[]}}`,
+		},
+	}
+	for _, tc := range cases {
+		stdout, stderr, code := captureRun(t, tc.args)
+		if code != tc.code || stdout != "" || stderr != tc.want+"\n" {
+			t.Fatalf("%v: code = %d stdout=%q stderr=%s", tc.args, code, stdout, stderr)
+		}
+	}
+
+	// The output flags may follow the error.
+	for _, args := range [][]string{
+		{"issue", "list", "--bogus", "-o", "json"},
+		{"issue", "list", "--limit", "x", "--output=jsonl"},
+		{"issue", "clos", "--json"},
+		{"issue", "show", "--json", "id", "--nope", "101"},
+	} {
+		_, stderr, code := captureRun(t, args)
+		if code != exitUsage || !strings.HasPrefix(stderr, `{"error":{"kind":"usage","message":`) {
+			t.Fatalf("%v: code = %d stderr=%s", args, code, stderr)
+		}
+	}
+
+	_, stderr, _ := captureRun(t, []string{"issue", "close"})
+	if !strings.HasPrefix(stderr, "unknown issue command: close\n") {
+		t.Fatalf("text error expected without JSON output: %s", stderr)
+	}
+	_, stderr, _ = captureRun(t, []string{"-o", "json", "issue", "list", "-o", "table", "--bogus"})
+	if !strings.HasPrefix(stderr, "error: unknown flag --bogus") {
+		t.Fatalf("text error expected after -o table: %s", stderr)
+	}
+}
+
+func TestLookupRequestErrorIsNotUsage(t *testing.T) {
+	fixture := easy8test.DefaultFixture()
+	fixture.APIKey = "other-key"
+	server := httptest.NewServer(easy8test.New(fixture))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	for _, args := range [][]string{
+		{"issue", "search", "--status", "New", "-o", "json"},
+		{"issue", "search", "--assignee", "alice", "-o", "json"},
+		{"--as-user", "alice", "issue", "list", "-o", "json"},
+	} {
+		_, stderr, code := captureRun(t, args)
+		if code != exitAuth || !strings.HasPrefix(stderr, `{"error":{"kind":"auth",`) || !strings.Contains(stderr, `"status_code":401`) {
+			t.Fatalf("%v: code = %d stderr=%s", args, code, stderr)
+		}
+	}
+}
+
+func TestWrappedAPIError(t *testing.T) {
+	inv := &invocation{}
+	err := fmt.Errorf("resolving --as-user: %w", api.APIError{StatusCode: 403, Body: "Forbidden"})
+	_, stderr, code := capture(t, func() int { return inv.apiError(err) })
+	if code != exitForbidden || stderr != "error: resolving --as-user: api error 403: Forbidden\n" {
+		t.Fatalf("code = %d stderr=%q", code, stderr)
+	}
+
+	err = fmt.Errorf("update: %w", api.APIError{StatusCode: 422, Errors: []string{"Subject cannot be blank"}})
+	_, stderr, code = capture(t, func() int { return inv.apiError(err) })
+	if code != exitValidation || stderr != "api error 422 (validation):\n  - Subject cannot be blank\n" {
+		t.Fatalf("code = %d stderr=%q", code, stderr)
+	}
+}
+
 func TestIssueSearchNameFilters(t *testing.T) {
 	server := newLookupServer(t)
 	setTestEnv(t, server.URL)
@@ -159,6 +456,218 @@ func TestIssueSearchNameFilters(t *testing.T) {
 	}
 }
 
+func TestIssueSearchFuzzyNames(t *testing.T) {
+	server := newLookupServer(t)
+	setTestEnv(t, server.URL)
+
+	args := []string{
+		"issue", "search",
+		"--assignee", "alic",
+		"--status", "nwe",
+		"--priority", "hig",
+		"--task-type", "task",
+		"--project", "projekt a",
+	}
+	stdout, stderr, code := captureRun(t, args)
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	if !strings.Contains(stdout, "Fix onboarding") {
+		t.Fatalf("unexpected stdout: %s", stdout)
+	}
+	if !strings.Contains(stderr, "note: status \"nwe\" matched \"New\" (id 2)") || strings.Contains(stderr, "task-type") {
+		t.Fatalf("unexpected stderr: %s", stderr)
+	}
+
+	// With JSON output stderr is left for error envelopes.
+	_, stderr, code = captureRun(t, append(args, "--json"))
+	if code != 0 || stderr != "" {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestPickCandidate(t *testing.T) {
+	items := []candidate{
+		{ID: 1, Label: "In Progress", Keys: []string{"In Progress"}},
+		{ID: 2, Label: "In Review", Keys: []string{"In Review"}},
+		{ID: 3, Label: "Petr Novák", Keys: []string{"Petr Novák"}},
+		{ID: 4, Label: "Resolved", Keys: []string{"Resolved"}},
+	}
+
+	for input, want := range map[string]int{"in progres": 1, "petr novak": 3, "NOVÁK": 3, "resolvd": 4} {
+		match, err := pickCandidate(items, input, "status", nil)
+		if err != nil || match.ID != want {
+			t.Fatalf("%q: match = %+v err=%v", input, match, err)
+		}
+	}
+
+	_, err := pickCandidate(items, "in", "status", nil)
+	var ambiguous ambiguousError
+	if !errors.As(err, &ambiguous) || len(ambiguous.candidates) != 2 {
+		t.Fatalf("err = %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "status matches multiple entries: in\ndid you mean:\n  - In Progress (id 1)\n  - In Review (id 2)") {
+		t.Fatalf("unexpected message: %q", err.Error())
+	}
+
+	_, err = pickCandidate(items, "zzz", "status", nil)
+	if err == nil || err.Error() != "status not found: zzz" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestIssueSearchUsesLookupCache(t *testing.T) {
+	statusHits := 0
+	nameSearches := 0
+	statuses := "{\"issue_statuses\":[{\"id\":2,\"name\":\"New\"}]}"
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "alice" {
+			t.Errorf("expected a name-filtered user lookup, got %s", r.URL.RawQuery)
+		}
+		nameSearches++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"users\":[{\"id\":11,\"login\":\"alice\",\"firstname\":\"Alice\",\"lastname\":\"Doe\"}],\"total_count\":1,\"offset\":0,\"limit\":100}"))
+	})
+	handler.HandleFunc("/issue_statuses.json", func(w http.ResponseWriter, r *http.Request) {
+		statusHits++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(statuses))
+	})
+	handler.HandleFunc("/issues.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("assigned_to_id") == "" && r.URL.Query().Get("status_id") == "" {
+			t.Errorf("missing resolved filters: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issues\":[{\"id\":101,\"subject\":\"Fix onboarding\"}],\"total_count\":1,\"offset\":0,\"limit\":25}"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	for i := 0; i < 2; i++ {
+		_, stderr, code := captureRun(t, []string{"issue", "search", "--assignee", "alice", "--status", "New"})
+		if code != 0 {
+			t.Fatalf("code = %d stderr=%s", code, stderr)
+		}
+	}
+	if statusHits != 1 || nameSearches != 2 {
+		t.Fatalf("status hits = %d name searches = %d", statusHits, nameSearches)
+	}
+
+	_, stderr, code := captureRun(t, []string{"--no-cache", "issue", "search", "--status", "New"})
+	if code != 0 || statusHits != 2 {
+		t.Fatalf("code = %d hits=%d stderr=%s", code, statusHits, stderr)
+	}
+
+	statuses = "{\"issue_statuses\":[{\"id\":2,\"name\":\"New\"},{\"id\":3,\"name\":\"Waiting\"}]}"
+	_, stderr, code = captureRun(t, []string{"issue", "search", "--status", "Waiting"})
+	if code != 0 {
+		t.Fatalf("miss should refetch: code = %d stderr=%s", code, stderr)
+	}
+
+	stdout, stderr, code := captureRun(t, []string{"cache", "info"})
+	if code != 0 || !strings.Contains(stdout, "statuses") {
+		t.Fatalf("code = %d stdout=%s stderr=%s", code, stdout, stderr)
+	}
+	stdout, _, code = captureRun(t, []string{"cache", "clear"})
+	if code != 0 || !strings.Contains(stdout, "Cleared") {
+		t.Fatalf("code = %d stdout=%s", code, stdout)
+	}
+	stdout, _, _ = captureRun(t, []string{"cache", "info"})
+	if !strings.Contains(stdout, "No cached lookups.") {
+		t.Fatalf("unexpected stdout: %s", stdout)
+	}
+}
+
+func TestIssueSearchAssigneeFromProjectMemberships(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users.json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	handler.HandleFunc("/projects.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"projects\":[{\"id\":7,\"name\":\"Website\"}],\"total_count\":1,\"offset\":0,\"limit\":100}"))
+	})
+	handler.HandleFunc("/projects/7/memberships.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"memberships\":[{\"id\":1,\"group\":{\"id\":90,\"name\":\"Alice Doe\"}},{\"id\":2,\"user\":{\"id\":11,\"name\":\"Alice Doe\"}}],\"total_count\":2,\"offset\":0,\"limit\":100}"))
+	})
+	handler.HandleFunc("/issues.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("assigned_to_id") != "11" || r.URL.Query().Get("project_id") != "7" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issues\":[],\"total_count\":0,\"offset\":0,\"limit\":25}"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	_, stderr, code := captureRun(t, []string{"issue", "search", "--assignee", "Alice Doe", "--project", "Website"})
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestIssueSearchAssigneeFallsBackToListing(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("name") != "" {
+			_, _ = w.Write([]byte("{\"users\":[{\"id\":12,\"login\":\"bob\",\"firstname\":\"Bob\",\"lastname\":\"Smith\"}],\"total_count\":1,\"offset\":0,\"limit\":100}"))
+			return
+		}
+		_, _ = w.Write([]byte("{\"users\":[{\"id\":11,\"login\":\"adoe\",\"firstname\":\"Alice\",\"lastname\":\"Doe\"},{\"id\":12,\"login\":\"bob\",\"firstname\":\"Bob\",\"lastname\":\"Smith\"}],\"total_count\":2,\"offset\":0,\"limit\":100}"))
+	})
+	handler.HandleFunc("/projects.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"projects\":[{\"id\":7,\"name\":\"Website\"}],\"total_count\":1,\"offset\":0,\"limit\":100}"))
+	})
+	handler.HandleFunc("/projects/7/memberships.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"memberships\":[{\"id\":2,\"user\":{\"id\":11,\"name\":\"Alice Doe\"}}],\"total_count\":1,\"offset\":0,\"limit\":100}"))
+	})
+	var assignees []string
+	handler.HandleFunc("/issues.json", func(w http.ResponseWriter, r *http.Request) {
+		assignees = append(assignees, r.URL.Query().Get("assigned_to_id"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issues\":[],\"total_count\":0,\"offset\":0,\"limit\":25}"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	// The name filter answers with someone else, and the login is not in
+	// the memberships; both fall through to the full listing.
+	for _, args := range [][]string{
+		{"--no-cache", "issue", "search", "--assignee", "Alice Doe"},
+		{"--no-cache", "issue", "search", "--assignee", "adoe", "--project", "Website"},
+	} {
+		if _, stderr, code := captureRun(t, args); code != 0 {
+			t.Fatalf("%v: code = %d stderr=%s", args, code, stderr)
+		}
+	}
+	if strings.Join(assignees, ",") != "11,11" {
+		t.Fatalf("assignees = %v", assignees)
+	}
+}
+
+func TestCacheRefresh(t *testing.T) {
+	server := newLookupServer(t)
+	setTestEnv(t, server.URL)
+
+	stdout, stderr, code := captureRun(t, []string{"cache", "refresh"})
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	for _, name := range []string{"users", "projects", "statuses", "trackers", "priorities"} {
+		if !strings.Contains(stdout, name) {
+			t.Fatalf("missing %s in %s", name, stdout)
+		}
+	}
+}
+
 func TestIssueSearchNameConflict(t *testing.T) {
 	server := newLookupServer(t)
 	setTestEnv(t, server.URL)
@@ -173,9 +682,128 @@ func TestIssueSearchNameConflict(t *testing.T) {
 	}
 }
 
+func TestIssueCreateAsUser(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Redmine-Switch-User") != "" {
+			t.Errorf("user lookup must not impersonate")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"users\":[{\"id\":11,\"login\":\"alice\",\"firstname\":\"Alice\",\"lastname\":\"Doe\"},{\"id\":12,\"login\":\"locked\",\"firstname\":\"Locked\",\"lastname\":\"User\"}],\"total_count\":2,\"offset\":0,\"limit\":100}"))
+	})
+	handler.HandleFunc("/issues.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Redmine-Switch-User") != "alice" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issue\":{\"id\":202,\"subject\":\"New task\"}}"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	create := []string{"issue", "create", "--subject", "New task", "--project-id", "1", "--tracker-id", "1", "--status-id", "1", "--priority-id", "1", "--author-id", "1", "--assigned-to-id", "2"}
+	stdout, stderr, code := captureRun(t, append([]string{"--as-user", "Alice Doe"}, create...))
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	if !strings.Contains(stdout, "New task") {
+		t.Fatalf("unexpected stdout: %s", stdout)
+	}
+
+	_, stderr, code = captureRun(t, append([]string{"--as-user", "locked"}, create...))
+	if code == 0 || !strings.Contains(stderr, "rejected impersonation") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+
+	_, stderr, code = captureRun(t, append([]string{"--as-user", "nobody"}, create...))
+	if code == 0 || !strings.Contains(stderr, "as-user not found") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+
+	// A prefix or a typo must not impersonate the closest user.
+	for _, name := range []string{"ali", "alce"} {
+		stdout, stderr, code = captureRun(t, append([]string{"--as-user", name}, create...))
+		if code == 0 || stdout != "" || !strings.Contains(stderr, "as-user not found: "+name) || !strings.Contains(stderr, "Alice Doe (alice) (id 11)") {
+			t.Fatalf("%s: code = %d stdout=%s stderr=%s", name, code, stdout, stderr)
+		}
+	}
+}
+
+func TestAuthRequiresOAuthMode(t *testing.T) {
+	setTestEnv(t, "https://example.com")
+
+	_, stderr, code := captureRun(t, []string{"auth", "status"})
+	if code != 2 {
+		t.Fatalf("code = %d", code)
+	}
+	if !strings.Contains(stderr, "auth_mode") {
+		t.Fatalf("unexpected stderr: %s", stderr)
+	}
+}
+
+func TestAuthLoginAndIssueList(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/oauth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		target := query.Get("redirect_uri") + "?code=auth-code&state=" + url.QueryEscape(query.Get("state"))
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+	handler.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"access_token\":\"access-1\",\"refresh_token\":\"refresh-1\"}"))
+	})
+	handler.HandleFunc("/issues.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{\"issues\":[{\"id\":101,\"subject\":\"Fix onboarding\"}],\"total_count\":1,\"offset\":0,\"limit\":25}"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	setTestEnv(t, server.URL)
+	t.Setenv("EASY8_API_KEY", "")
+	t.Setenv("EASY8_AUTH_MODE", "oauth2")
+	t.Setenv("EASY8_OAUTH_CLIENT_ID", "cli")
+
+	oldOpen := openBrowser
+	openBrowser = func(target string) error {
+		resp, err := http.Get(target)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	defer func() { openBrowser = oldOpen }()
+
+	stdout, stderr, code := captureRun(t, []string{"auth", "login"})
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	if !strings.Contains(stdout, "Logged in") {
+		t.Fatalf("unexpected stdout: %s", stdout)
+	}
+
+	stdout, stderr, code = captureRun(t, []string{"issue", "list"})
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	if !strings.Contains(stdout, "Fix onboarding") {
+		t.Fatalf("unexpected stdout: %s", stdout)
+	}
+}
+
 func setTestHome(t *testing.T) {
 	t.Helper()
 	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("EASY8_PROFILE", "")
+	t.Setenv("EASY8_AUTH_MODE", "")
+	t.Setenv("EASY8_AS_USER", "")
 }
 
 func setTestEnv(t *testing.T, baseURL string) {
@@ -186,6 +814,12 @@ func setTestEnv(t *testing.T, baseURL string) {
 }
 
 func captureRun(t *testing.T, args []string) (string, string, int) {
+	t.Helper()
+	return capture(t, func() int { return Run(args) })
+}
+
+// capture runs fn with os.Stdout and os.Stderr redirected.
+func capture(t *testing.T, fn func() int) (string, string, int) {
 	t.Helper()
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
@@ -204,7 +838,7 @@ func captureRun(t *testing.T, args []string) (string, string, int) {
 	os.Stdout = stdoutWriter
 	os.Stderr = stderrWriter
 
-	code := Run(args)
+	code := fn()
 
 	_ = stdoutWriter.Close()
 	_ = stderrWriter.Close()
@@ -310,3 +944,564 @@ func newErrorServer(t *testing.T) *httptest.Server {
 	})
 	return httptest.NewServer(handler)
 }
+
+func TestAgainstMockServer(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	stdout, stderr, code := captureRun(t, []string{"issue", "create", "--subject", "Rotate API keys", "--project-id", "1", "--tracker-id", "3", "--status-id", "1", "--priority-id", "2", "--author-id", "1", "--assigned-to-id", "11", "--json"})
+	if code != 0 || !strings.Contains(stdout, "\"id\": 106") {
+		t.Fatalf("code = %d stdout=%s stderr=%s", code, stdout, stderr)
+	}
+
+	stdout, stderr, code = captureRun(t, []string{"--no-cache", "issue", "search", "--q", "rotate", "--status", "New", "--project", "Website", "--json"})
+	if code != 0 || !strings.Contains(stdout, "Rotate API keys") || strings.Contains(stdout, "Fix onboarding") {
+		t.Fatalf("code = %d stdout=%s stderr=%s", code, stdout, stderr)
+	}
+
+	_, stderr, code = captureRun(t, []string{"dev", "mock-server", "--fixture", filepath.Join(t.TempDir(), "missing.json")})
+	if code != exitUsage || !strings.HasPrefix(stderr, "error: invalid --fixture:") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	_, stderr, code = captureRun(t, []string{"-o", "json", "dev", "mock-server", "--fixture", filepath.Join(t.TempDir(), "missing.json")})
+	if code != exitUsage || !strings.HasPrefix(stderr, `{"error":{"kind":"usage","message":"invalid --fixture:`) {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--output", "csv", "issue", "list", "--limit", "2"}, "ID,Subject,Status,Assignee,Updated\n105,Release 2.0,Closed,,2023-12-20T12:00:00Z\n104,Crash on login with SSO,New,Alice Doe,2024-01-05T09:00:00Z\n"},
+		{[]string{"issue", "list", "--limit", "1", "-o", "tsv"}, "ID\tSubject\tStatus\tAssignee\tUpdated\n105\tRelease 2.0\tClosed\t\t2023-12-20T12:00:00Z\n"},
+		{[]string{"-o", "markdown", "issue", "list", "--limit", "1"}, "| ID | Subject | Status | Assignee | Updated |\n| --- | --- | --- | --- | --- |\n| 105 | Release 2.0 | Closed |  | 2023-12-20T12:00:00Z |\n"},
+		{[]string{"issue", "update", "--id", "102", "--done-ratio", "50", "--output", "jsonl"}, "\"done_ratio\":50"},
+		{[]string{"--output", "yaml", "issue", "list", "--limit", "1"}, "issues:\n  - id: 105\n    subject: Release 2.0\n    updated_on: \"2023-12-20T12:00:00Z\"\n"},
+		{[]string{"--output", "csv", "issue", "list", "--limit", "1", "--json"}, "\"total_count\": 5"},
+	}
+	for _, tc := range cases {
+		stdout, stderr, code := captureRun(t, tc.args)
+		if code != 0 || !strings.Contains(stdout, tc.want) {
+			t.Fatalf("%v: code = %d stdout=%q stderr=%s", tc.args, code, stdout, stderr)
+		}
+	}
+
+	stdout, _, _ := captureRun(t, []string{"issue", "list", "--limit", "3", "-o", "jsonl"})
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	for _, line := range lines {
+		var issue api.Issue
+		if err := json.Unmarshal([]byte(line), &issue); err != nil || issue.ID == 0 {
+			t.Fatalf("bad jsonl line %q: %v", line, err)
+		}
+	}
+	if len(lines) != 3 {
+		t.Fatalf("jsonl lines = %d", len(lines))
+	}
+
+	stdout, stderr, code := captureRun(t, []string{"cache", "info", "--output", "json"})
+	if code != 0 || !strings.Contains(stdout, "\"tables\": []") {
+		t.Fatalf("code = %d stdout=%s stderr=%s", code, stdout, stderr)
+	}
+
+	_, stderr, code = captureRun(t, []string{"--output", "xml", "issue", "list"})
+	if code != exitUsage || !strings.Contains(stderr, "unknown output format \"xml\"") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestYAMLQuoting(t *testing.T) {
+	value := map[string]any{"empty": "", "flag": "yes", "number": "42", "colon": "a: b", "plain": "Petr Novák", "list": []any{map[string]any{"a": 1, "b": []any{}}}, "none": nil}
+	var out bytes.Buffer
+	if err := writeYAML(&out, document{value: value}); err != nil {
+		t.Fatalf("writeYAML: %v", err)
+	}
+	want := "colon: \"a: b\"\nempty: \"\"\nflag: \"yes\"\nlist:\n  - a: 1\n    b: []\nnone: null\nnumber: \"42\"\nplain: Petr Novák\n"
+	if out.String() != want {
+		t.Fatalf("yaml = %q", out.String())
+	}
+}
+
+func TestTemplateOutput(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+	home := os.Getenv("HOME")
+	configDir := filepath.Join(home, ".config", "easy8")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	config := `{"templates": {"short": "#{{.ID}} {{nameOf .Status}}"}}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(config), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	templateFile := filepath.Join(t.TempDir(), "row.tmpl")
+	if err := os.WriteFile(templateFile, []byte("{{.ID}}\t{{.Subject}}\n"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"issue", "list", "--limit", "2", "--template", "{{.ID}} {{.Subject | truncate 10}} {{nameOf .AssignedTo | pad 9}}|"}, "105 Release 2… " + strings.Repeat(" ", 9) + "|\n104 Crash on … Alice Doe|\n"},
+		{[]string{"--template", "short", "issue", "list", "--limit", "2"}, "#105 Closed\n#104 New\n"},
+		{[]string{"issue", "list", "--limit", "1", "--template-file", templateFile}, "105\tRelease 2.0\n"},
+		{[]string{"issue", "update", "--id", "102", "--done-ratio", "60", "--template", "{{.ID}}: {{.DoneRatio}}%"}, "102: 60%\n"},
+	}
+	for _, tc := range cases {
+		stdout, stderr, code := captureRun(t, tc.args)
+		if code != 0 || stdout != tc.want {
+			t.Fatalf("%v: code = %d stdout=%q stderr=%s", tc.args, code, stdout, stderr)
+		}
+	}
+
+	_, stderr, code := captureRun(t, []string{"issue", "list", "--template", "long"})
+	if code != exitUsage || !strings.Contains(stderr, `unknown template "long" (config has: short)`) {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	_, stderr, code = captureRun(t, []string{"issue", "create", "--subject", "x", "--template", "{{.ID"})
+	if code != exitUsage || !strings.Contains(stderr, "template:") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	_, stderr, code = captureRun(t, []string{"issue", "list", "--template", "{{.Missing}}"})
+	if code != exitError || !strings.Contains(stderr, "template error:") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	oldNow, oldTerminal := timeNow, stdoutIsTerminal
+	defer func() { timeNow, stdoutIsTerminal = oldNow, oldTerminal }()
+	timeNow = func() time.Time { return time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC) }
+	stdoutIsTerminal = func() bool { return true }
+	t.Setenv("NO_COLOR", "")
+
+	tmpl, err := parseTemplate(`{{timeago .Updated}}|{{timeago .Due}}|{{join ", " .Watchers}}|{{join "/" .Tags}}|{{color "red" .Name}}|{{pad -5 .Count}}`, "", nil, true)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var out bytes.Buffer
+	data := map[string]any{
+		"Updated":  "2024-01-07T11:00:00Z",
+		"Due":      "2024-03-01T12:00:00Z",
+		"Watchers": []api.NamedRef{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}},
+		"Tags":     []string{"ui", "bug"},
+		"Name":     "late",
+		"Count":    42,
+	}
+	if err := tmpl.Execute(&out, data); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	want := "3 days ago|in 1 month|Alice, Bob|ui/bug|\x1b[31mlate\x1b[0m|   42"
+	if out.String() != want {
+		t.Fatalf("got %q", out.String())
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if useColor("auto") || !useColor("always") {
+		t.Fatalf("NO_COLOR ignored or --color=always overridden")
+	}
+	if s, _ := color("red", "x", false); s != "x" {
+		t.Fatalf("color without a terminal: %q", s)
+	}
+	if _, err := color("chartreuse", "x", true); err == nil {
+		t.Fatalf("expected unknown color error")
+	}
+	if _, err := parseTemplate("x", "file", nil, false); err == nil {
+		t.Fatalf("expected error for --template with --template-file")
+	}
+}
+
+func TestIssueColumns(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+	t.Setenv("COLUMNS", "40")
+
+	// $COLUMNS only applies on a terminal.
+	stdout, stderr, code := captureRun(t, []string{"issue", "list", "--limit", "2"})
+	if code != 0 || !strings.Contains(stdout, "Crash on login with SSO") {
+		t.Fatalf("code = %d stdout=%s stderr=%s", code, stdout, stderr)
+	}
+
+	oldTerminal := stdoutIsTerminal
+	defer func() { stdoutIsTerminal = oldTerminal }()
+	stdoutIsTerminal = func() bool { return true }
+	stdout, stderr, code = captureRun(t, []string{"--color", "never", "--no-pager", "issue", "list", "--limit", "2"})
+	if code != 0 || !strings.Contains(stdout, "Crash o…") || !strings.Contains(stdout, "Alice D…") || strings.Contains(stdout, "Crash on login") {
+		t.Fatalf("code = %d stdout=%s stderr=%s", code, stdout, stderr)
+	}
+	stdout, _, code = captureRun(t, []string{"--color", "never", "--no-pager", "issue", "list", "--limit", "2", "--wide"})
+	if code != 0 || !strings.Contains(stdout, "Crash on login with SSO") {
+		t.Fatalf("code = %d stdout=%s", code, stdout)
+	}
+	stdoutIsTerminal = oldTerminal
+
+	stdout, stderr, code = captureRun(t, []string{"issue", "list", "--columns", "id,priority,due_date,project,cf:Customer", "-o", "csv"})
+	if code != 0 || !strings.HasPrefix(stdout, "ID,Priority,Due,Project,Customer\n") || !strings.Contains(stdout, "\n104,Urgent,2024-01-08,Mobile App,Globex\n") {
+		t.Fatalf("code = %d stdout=%s stderr=%s", code, stdout, stderr)
+	}
+	if stderr != "" {
+		t.Fatalf("unexpected stderr: %s", stderr)
+	}
+	stdout, stderr, code = captureRun(t, []string{"issue", "list", "--columns", "id,cf:Custmer", "-o", "csv"})
+	if code != 0 || !strings.Contains(stdout, "\n104,\n") || stderr != "warning: no issue has the custom field \"Custmer\" (--columns cf:Custmer)\n" {
+		t.Fatalf("code = %d stdout=%s stderr=%q", code, stdout, stderr)
+	}
+	_, stderr, code = captureRun(t, []string{"issue", "list", "--columns", "id,bogus"})
+	if code != exitUsage || !strings.Contains(stderr, `unknown column "bogus"`) {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+
+	configDir := filepath.Join(os.Getenv("HOME"), ".config", "easy8")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"columns": ["id", "cf:7"]}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	stdout, stderr, code = captureRun(t, []string{"issue", "search", "--subject", "~onboarding", "-o", "csv"})
+	if code != 0 || stdout != "ID,7\n101,Acme Corporation\n" {
+		t.Fatalf("code = %d stdout=%q stderr=%s", code, stdout, stderr)
+	}
+}
+
+func TestJSONFieldsAndQuery(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"issue", "list", "--limit", "2", "--json", "id,subject,status.name,assigned_to.name"}, `{
+  "issues": [
+    {
+      "id": 105,
+      "subject": "Release 2.0",
+      "status": {
+        "name": "Closed"
+      },
+      "assigned_to": {
+        "name": null
+      }
+    },
+    {
+      "id": 104,
+      "subject": "Crash on login with SSO",
+      "status": {
+        "name": "New"
+      },
+      "assigned_to": {
+        "name": "Alice Doe"
+      }
+    }
+  ],
+  "total_count": 5,
+  "offset": 0,
+  "limit": 2
+}
+`},
+		{[]string{"issue", "update", "--id", "102", "--done-ratio", "60", "--json=id,done_ratio"}, "{\n  \"issue\": {\n    \"id\": 102,\n    \"done_ratio\": 60\n  }\n}\n"},
+		{[]string{"issue", "search", "--status", "New", "--json", "id", "-o", "jsonl"}, "{\"id\":104}\n{\"id\":101}\n"},
+		{[]string{"issue", "list", "--query", "issues[?status.name == 'New'].id"}, "[\n  104,\n  101\n]\n"},
+		{[]string{"issue", "list", "--query", "issues[?status.name == 'New'].{id: id}", "-o", "jsonl"}, "{\"id\":104}\n{\"id\":101}\n"},
+		{[]string{"--jq", "issues[0].subject", "issue", "list"}, "Release 2.0\n"},
+		{[]string{"issue", "list", "--json", "id", "--jq", "issues[*].id", "-o", "jsonl"}, "105\n104\n103\n102\n101\n"},
+		{[]string{"issue", "list", "--json", "id,priority.name", "--jq", "issues[?priority.name == 'Urgent'] | [0].id"}, "104\n"},
+		{[]string{"issue", "search", "--subject", "~nothing matches", "--json", "id", "--jq", "issues"}, "[]\n"},
+	}
+	for _, tc := range cases {
+		stdout, stderr, code := captureRun(t, tc.args)
+		if code != 0 || stdout != tc.want {
+			t.Fatalf("%v: code = %d stdout=%q stderr=%s", tc.args, code, stdout, stderr)
+		}
+	}
+
+	usage := map[string][]string{
+		`unknown JSON field \"subjct\"`: {"issue", "list", "--json", "id,subjct"},
+		"invalid --query":               {"issue", "list", "--query", "issues[?"},
+		"cannot be combined":            {"issue", "list", "--query", "issues", "--template", "{{.ID}}"},
+		"--output csv":                  {"issue", "list", "--json", "id", "-o", "csv"},
+	}
+	for want, args := range usage {
+		_, stderr, code := captureRun(t, args)
+		if code != exitUsage || !strings.Contains(stderr, want) {
+			t.Fatalf("%v: code = %d stderr=%s", args, code, stderr)
+		}
+	}
+	_, stderr, code := captureRun(t, []string{"issue", "list", "--query", "length(total_count)"})
+	if code != 1 || !strings.Contains(stderr, "query error: length(): expected string, array or object, got number") {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+}
+
+func TestColoredTable(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+	oldNow := timeNow
+	defer func() { timeNow = oldNow }()
+	timeNow = func() time.Time { return time.Date(2024, 1, 9, 12, 0, 0, 0, time.UTC) }
+
+	args := []string{"issue", "list", "--columns", "id,status,priority,due_date,updated"}
+	plain, stderr, code := captureRun(t, append([]string{"--color", "never"}, args...))
+	want := "ID   Status       Priority  Due         Updated\n" +
+		"105  Closed       Normal                2w ago\n" +
+		"104  New          Urgent    2024-01-08  4d ago\n" +
+		"103  Resolved     Low                   3d ago\n" +
+		"102  In Progress  Normal                3d ago\n" +
+		"101  New          High      2024-01-10  1w ago\n"
+	if code != 0 || plain != want {
+		t.Fatalf("code = %d stdout=%q stderr=%s", code, plain, stderr)
+	}
+
+	colored, stderr, code := captureRun(t, append([]string{"--color", "always"}, args...))
+	if code != 0 {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	for _, cell := range []string{"\x1b[1mID\x1b[0m", "\x1b[90mClosed\x1b[0m", "\x1b[32mNew\x1b[0m", "\x1b[31mUrgent\x1b[0m", "\x1b[33mHigh\x1b[0m", "\x1b[90mLow\x1b[0m", "\x1b[31m2024-01-08\x1b[0m"} {
+		if !strings.Contains(colored, cell) {
+			t.Fatalf("missing %q in %q", cell, colored)
+		}
+	}
+	if strings.Contains(colored, "\x1b[31m2024-01-10") {
+		t.Fatalf("due date in the future marked overdue: %q", colored)
+	}
+	stripped := strings.NewReplacer("\x1b[0m", "", "\x1b[1m", "", "\x1b[31m", "", "\x1b[32m", "", "\x1b[33m", "", "\x1b[90m", "").Replace(colored)
+	if stripped != plain {
+		t.Fatalf("colored layout differs:\n%s\n%s", stripped, plain)
+	}
+
+	stdout, _, _ := captureRun(t, append([]string{"-o", "csv", "--color", "always"}, args...))
+	if strings.Contains(stdout, "\x1b[") || !strings.Contains(stdout, "2024-01-05T09:00:00Z") {
+		t.Fatalf("csv output changed: %q", stdout)
+	}
+	_, stderr, code = captureRun(t, []string{"--color", "sometimes", "issue", "list"})
+	if code != exitUsage || !strings.Contains(stderr, `unknown color mode "sometimes"`) {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+
+	configDir := filepath.Join(os.Getenv("HOME"), ".config", "easy8")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"color": "always"}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if stdout, _, _ := captureRun(t, args); !strings.Contains(stdout, "\x1b[32mNew") {
+		t.Fatalf("config color ignored: %q", stdout)
+	}
+	if stdout, _, _ := captureRun(t, append([]string{"--color", "never"}, args...)); stdout != plain {
+		t.Fatalf("--color never did not override the config: %q", stdout)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"issue", "list", "--group-by", "status", "--sum", "--columns", "id,subject"}, "ID   Subject\n" +
+			"\nNew (2 issues, 12h estimated, 3.5h spent)\n104  Crash on login with SSO\n101  Fix onboarding email\n" +
+			"\nIn Progress (1 issue, 16h estimated, 6h spent)\n102  Add dark mode\n" +
+			"\nResolved (1 issue, 2h estimated, 2h spent)\n103  Update privacy policy\n" +
+			"\nClosed (1 issue, 0h estimated, 0h spent)\n105  Release 2.0\n"},
+		{[]string{"issue", "list", "--group-by", "status,assignee", "--summary"}, "" +
+			"Status / Assignee  Alice Doe  Bob Smith  Petr Novák  (none)  Total\n" +
+			"New                2          0          0           0       2\n" +
+			"In Progress        0          0          1           0       1\n" +
+			"Resolved           0          1          0           0       1\n" +
+			"Closed             0          0          0           1       1\n" +
+			"Total              2          1          1           1       5\n"},
+		{[]string{"issue", "search", "--status", "New", "--group-by", "assignee", "--summary", "--sum", "-o", "csv"}, "Assignee,Issues,Estimated,Spent\nAlice Doe,2,12,3.5\nTotal,2,12,3.5\n"},
+		{[]string{"issue", "list", "--group-by", "project", "--columns", "id", "-o", "csv"}, "Project,ID\nMobile App,105\nMobile App,104\nMobile App,102\nWebsite,103\nWebsite,101\n"},
+		{[]string{"issue", "list", "--group-by", "priority", "--query", "groups[*].[priority.name, count]"}, "[\n  [\n    \"Low\",\n    1\n  ],\n  [\n    \"Normal\",\n    2\n  ],\n  [\n    \"High\",\n    1\n  ],\n  [\n    \"Urgent\",\n    1\n  ]\n]\n"},
+	}
+	for _, tc := range cases {
+		stdout, stderr, code := captureRun(t, tc.args)
+		if code != 0 || stdout != tc.want {
+			t.Fatalf("%v: code = %d stdout=%q stderr=%s", tc.args, code, stdout, stderr)
+		}
+	}
+
+	stdout, _, code := captureRun(t, []string{"issue", "list", "--group-by", "assignee", "--json"})
+	var grouped struct {
+		Groups []struct {
+			Assignee *api.NamedRef `json:"assignee"`
+			Count    int           `json:"count"`
+			Issues   []api.Issue   `json:"issues"`
+		} `json:"groups"`
+		Total struct {
+			Count int `json:"count"`
+		} `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &grouped); err != nil || code != 0 {
+		t.Fatalf("code = %d err = %v stdout=%s", code, err, stdout)
+	}
+	last := grouped.Groups[len(grouped.Groups)-1]
+	if len(grouped.Groups) != 4 || grouped.Groups[0].Assignee.Name != "Alice Doe" || len(grouped.Groups[0].Issues) != 2 || last.Assignee != nil || last.Issues[0].ID != 105 || grouped.Total.Count != 5 {
+		t.Fatalf("unexpected groups: %s", stdout)
+	}
+
+	// Grouping covers one page unless --all is given.
+	stdout, stderr, code := captureRun(t, []string{"issue", "list", "--limit", "2", "--group-by", "status", "--summary", "--query", "[total.count, total_count, groups[*].count]", "-o", "jsonl"})
+	if code != 0 || stdout != "2\n5\n[1,1]\n" || stderr != "" {
+		t.Fatalf("code = %d stdout=%q stderr=%s", code, stdout, stderr)
+	}
+	_, stderr, code = captureRun(t, []string{"issue", "list", "--limit", "2", "--group-by", "status", "--summary"})
+	if code != 0 || stderr != "note: grouped 2 of 5 issues (use --all to group every issue)\n" {
+		t.Fatalf("code = %d stderr=%s", code, stderr)
+	}
+	stdout, stderr, code = captureRun(t, []string{"issue", "list", "--all", "--page-size", "2", "--group-by", "status", "--summary", "--query", "[total.count, total_count, groups[*].count]", "-o", "jsonl"})
+	if code != 0 || stdout != "5\n5\n[2,1,1,1]\n" || stderr != "" {
+		t.Fatalf("code = %d stdout=%q stderr=%s", code, stdout, stderr)
+	}
+
+	usage := map[string][]string{
+		"--sum and --summary need --group-by": {"issue", "list", "--summary"},
+		`unknown group "owner"`:               {"issue", "list", "--group-by", "owner"},
+		"one or two fields":                   {"issue", "list", "--group-by", "status,assignee,project"},
+		"cannot be combined":                  {"issue", "list", "--group-by", "status", "--template", "{{.ID}}"},
+	}
+	for want, args := range usage {
+		_, stderr, code := captureRun(t, args)
+		if code != exitUsage || !strings.Contains(stderr, want) {
+			t.Fatalf("%v: code = %d stderr=%s", args, code, stderr)
+		}
+	}
+}
+
+func TestPager(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+	oldTerminal := stdoutIsTerminal
+	defer func() { stdoutIsTerminal = oldTerminal }()
+	stdoutIsTerminal = func() bool { return true }
+	t.Setenv("LINES", "4")
+	t.Setenv("COLUMNS", "200")
+	t.Setenv("EASY8_PAGER", "tr a-z A-Z")
+
+	args := []string{"--color", "never", "issue", "list", "--columns", "id,subject"}
+	stdout, stderr, code := captureRun(t, args)
+	if code != 0 || !strings.Contains(stdout, "105  RELEASE 2.0\n") {
+		t.Fatalf("output not paged: code = %d stdout=%q stderr=%s", code, stdout, stderr)
+	}
+	cases := [][]string{
+		append([]string{"--no-pager"}, args...),
+		append(args, "--limit", "2"),
+	}
+	for _, args := range cases {
+		stdout, _, code := captureRun(t, args)
+		if code != 0 || !strings.Contains(stdout, "105  Release 2.0\n") {
+			t.Fatalf("%v: paged unexpectedly: %q", args, stdout)
+		}
+	}
+	t.Setenv("EASY8_PAGER", "")
+	if stdout, _, _ := captureRun(t, args); !strings.Contains(stdout, "105  Release 2.0\n") {
+		t.Fatalf("empty EASY8_PAGER did not disable paging: %q", stdout)
+	}
+
+	t.Setenv("EASY8_PAGER", "tr a-z A-Z")
+	var out bytes.Buffer
+	if code := run(args, &out); code != 0 || out.String() != "ID   Subject\n105  Release 2.0\n104  Crash on login with SSO\n103  Update privacy policy\n102  Add dark mode\n101  Fix onboarding email\n" {
+		t.Fatalf("code = %d output=%q", code, out.String())
+	}
+}
+
+func TestIssueShowIncludes(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	stdout, stderr, code := captureRun(t, []string{"issue", "show", "101", "--include", "journals,attachments", "--columns", "id", "--color", "never"})
+	want := "ID\n101\n\nAttachments:\n  email.png (24576 bytes, Admin User)\n\nHistory:\n  2024-01-02T10:00:00Z  Alice Doe\n    assigned_to_id: - -> 11\n    The template is missing the link.\n"
+	if code != 0 || stdout != want {
+		t.Fatalf("code = %d stdout=%q stderr=%s", code, stdout, stderr)
+	}
+
+	stdout, stderr, code = captureRun(t, []string{"issue", "show", "101", "--include", "journals", "--json", "journals,attachments"})
+	if code != 0 || !strings.Contains(stdout, `"notes": "The template is missing the link."`) || !strings.Contains(stdout, `"attachments": null`) {
+		t.Fatalf("code = %d stdout=%s stderr=%s", code, stdout, stderr)
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+
+	ok := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"issue", "show", "101", "--json", "id,subject"}, "{\n  \"issue\": {\n    \"id\": 101,\n    \"subject\": \"Fix onboarding email\"\n  }\n}\n"},
+		{[]string{"issue", "show", "--json=id", "--", "#104"}, "{\n  \"issue\": {\n    \"id\": 104\n  }\n}\n"},
+		{[]string{"issue", "update", "102", "--done-ratio", "70", "--template", "{{.ID}}: {{.DoneRatio}}%"}, "102: 70%\n"},
+		{[]string{"issue", "update", "101", "--status", "In Progress", "--template", "{{nameOf .Status}}"}, "In Progress\n"},
+		{[]string{"issue", "update", "103", "--priority", "urgent", "--assignee", "bob", "--template", "{{nameOf .Priority}}, {{nameOf .AssignedTo}}"}, "Urgent, Bob Smith\n"},
+		{[]string{"issue", "list", "--limit", "1", "--columns", "id", "--color", "never", "--output", "csv"}, "ID\n105\n"},
+	}
+	for _, tc := range ok {
+		stdout, stderr, code := captureRun(t, tc.args)
+		if code != 0 || stdout != tc.want {
+			t.Fatalf("%v: code = %d stdout=%q stderr=%s", tc.args, code, stdout, stderr)
+		}
+	}
+
+	_, stderr, code := captureRun(t, []string{"issue", "show", "101", "--timeout", "1ns"})
+	if code != exitTimeout {
+		t.Fatalf("global flag after the command ignored: code = %d stderr=%s", code, stderr)
+	}
+
+	usage := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"issue", "list", "--limt", "5"}, `error: unknown flag --limt for "easy8 issue list" (did you mean --limit?)`},
+		{[]string{"--verbsoe", "issue", "list"}, `error: unknown flag --verbsoe for "easy8" (did you mean --verbose?)`},
+		{[]string{"issue", "shwo", "101"}, "unknown issue command: shwo (did you mean show?)"},
+		{[]string{"issue", "show"}, "error: easy8 issue show needs <id>"},
+		{[]string{"issue", "show", "101", "102"}, `error: easy8 issue show takes <id>, got "101 102"`},
+		{[]string{"cache", "clear", "now"}, `error: easy8 cache clear takes no arguments, got "now"`},
+		{[]string{"issue", "update", "101", "--id", "102"}, "error: issue ID given twice: 101 and --id 102"},
+		{[]string{"issue", "update", "101", "--status", "Bogus"}, "error: status not found: Bogus"},
+		{[]string{"issue", "update", "101", "--status", "New", "--status-id", "2"}, "error: status-id does not match status name"},
+		{[]string{"issue", "show", "abc"}, `error: invalid issue ID "abc"`},
+	}
+	for _, tc := range usage {
+		_, stderr, code := captureRun(t, tc.args)
+		if code != exitUsage || !strings.HasPrefix(stderr, tc.want+"\n") {
+			t.Fatalf("%v: code = %d stderr=%s", tc.args, code, stderr)
+		}
+	}
+
+	help := map[string][]string{
+		"Usage:\n  easy8 issue list [flags]\n":      {"issue", "list", "--help"},
+		"  --limit <int>":                           {"issue", "list", "-h"},
+		"Limit (max 100) (default 25)\n":            {"help", "issue", "list"},
+		"Usage:\n  easy8 issue show <id> [flags]\n": {"issue", "help", "show"},
+		"  easy8 issue update <id> [flags]\n":       {"issue", "--help"},
+		"  issue show <id>    Show an issue\n":      {"--help"},
+		"  -o, --output <format>":                   {"help"},
+	}
+	for want, args := range help {
+		_, stderr, code := captureRun(t, args)
+		if code != 0 || !strings.Contains(stderr, want) {
+			t.Fatalf("%v: code = %d, want %q in stderr=%s", args, code, want, stderr)
+		}
+	}
+}
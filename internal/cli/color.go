@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+var colorModes = []string{"auto", "always", "never"}
+
+func checkColorMode(mode string) error {
+	for _, known := range colorModes {
+		if mode == "" || mode == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown color mode %q (use %s)", mode, strings.Join(colorModes, ", "))
+}
+
+// useColor decides the color mode: auto colors only a terminal without
+// NO_COLOR set.
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && stdoutIsTerminal()
+}
+
+func paint(name, s string) string {
+	code, ok := ansiColors[name]
+	if !ok || s == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// issueStyles colors issue table cells: statuses by open/closed, priorities
+// by their position relative to the default one and overdue due dates.
+type issueStyles struct {
+	closed   map[int]bool
+	priority map[int]string
+}
+
+// loadIssueStyles reads statuses and priorities from the lookup cache. A
+// failed lookup only leaves the affected cells uncolored.
+func loadIssueStyles(ctx context.Context, lookups lookupSource) issueStyles {
+	var styles issueStyles
+	if statuses, err := lookups.ListIssueStatuses(ctx); err == nil {
+		styles.closed = make(map[int]bool, len(statuses))
+		for _, status := range statuses {
+			styles.closed[status.ID] = status.IsClosed
+		}
+	}
+	if priorities, err := lookups.ListIssuePriorities(ctx); err == nil {
+		styles.priority = priorityColors(priorities)
+	}
+	return styles
+}
+
+// priorityColors dims priorities below the default, shows those above it in
+// yellow and the highest one in red.
+func priorityColors(priorities []api.IssuePriority) map[int]string {
+	colors := make(map[int]string, len(priorities))
+	normal := -1
+	for i, priority := range priorities {
+		if priority.IsDefault {
+			normal = i
+		}
+	}
+	for i, priority := range priorities {
+		switch {
+		case i == len(priorities)-1 && i > max(normal, 0):
+			colors[priority.ID] = "red"
+		case normal >= 0 && i < normal:
+			colors[priority.ID] = "gray"
+		case normal >= 0 && i > normal:
+			colors[priority.ID] = "yellow"
+		}
+	}
+	return colors
+}
+
+func (s issueStyles) isClosed(issue api.Issue) bool {
+	return issue.Status != nil && s.closed[issue.Status.ID]
+}
+
+func (s issueStyles) status(issue api.Issue) string {
+	if issue.Status == nil || s.closed == nil {
+		return ""
+	}
+	if s.isClosed(issue) {
+		return "gray"
+	}
+	return "green"
+}
+
+func (s issueStyles) priorityColor(issue api.Issue) string {
+	if issue.Priority == nil {
+		return ""
+	}
+	return s.priority[issue.Priority.ID]
+}
+
+func (s issueStyles) due(issue api.Issue) string {
+	if issue.DueDate != "" && issue.DueDate < timeNow().Format("2006-01-02") && !s.isClosed(issue) {
+		return "red"
+	}
+	return ""
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+var defaultIssueColumns = []string{"id", "subject", "status", "assignee", "updated"}
+
+// minColumnWidth is how far a flexible column may shrink to fit the terminal.
+const minColumnWidth = 8
+
+// issueColumn is one column of the issue table. Flexible columns are
+// truncated when the table is wider than the terminal; display replaces the
+// value in the table format and style picks the cell color. customField is
+// set for cf: columns and matches the field they show.
+type issueColumn struct {
+	header      string
+	flexible    bool
+	value       func(api.Issue) string
+	display     func(api.Issue) string
+	style       func(issueStyles, api.Issue) string
+	customField func(api.CustomField) bool
+}
+
+var issueColumns = map[string]issueColumn{
+	"id":              {header: "ID", value: func(issue api.Issue) string { return strconv.Itoa(issue.ID) }},
+	"subject":         {header: "Subject", flexible: true, value: func(issue api.Issue) string { return issue.Subject }},
+	"description":     {header: "Description", flexible: true, value: func(issue api.Issue) string { return strings.Join(strings.Fields(issue.Description), " ") }},
+	"status":          {header: "Status", value: func(issue api.Issue) string { return nameOrEmpty(issue.Status) }, style: issueStyles.status},
+	"priority":        {header: "Priority", value: func(issue api.Issue) string { return nameOrEmpty(issue.Priority) }, style: issueStyles.priorityColor},
+	"tracker":         {header: "Tracker", value: func(issue api.Issue) string { return nameOrEmpty(issue.Tracker) }},
+	"project":         {header: "Project", flexible: true, value: func(issue api.Issue) string { return nameOrEmpty(issue.Project) }},
+	"assignee":        {header: "Assignee", flexible: true, value: func(issue api.Issue) string { return nameOrEmpty(issue.AssignedTo) }},
+	"author":          {header: "Author", flexible: true, value: func(issue api.Issue) string { return nameOrEmpty(issue.Author) }},
+	"done_ratio":      {header: "Done", value: func(issue api.Issue) string { return strconv.Itoa(issue.DoneRatio) + "%" }},
+	"estimated_hours": {header: "Est.", value: func(issue api.Issue) string { return formatHours(issue.EstimatedHours) }},
+	"spent_hours":     {header: "Spent", value: func(issue api.Issue) string { return formatHours(issue.SpentHours) }},
+	"start_date":      {header: "Start", value: func(issue api.Issue) string { return issue.StartDate }},
+	"due_date":        {header: "Due", value: func(issue api.Issue) string { return issue.DueDate }, style: issueStyles.due},
+	"created":         {header: "Created", value: func(issue api.Issue) string { return issue.CreatedOn }, display: func(issue api.Issue) string { return shortTimeAgo(issue.CreatedOn) }},
+	"updated":         {header: "Updated", value: func(issue api.Issue) string { return issue.UpdatedOn }, display: func(issue api.Issue) string { return shortTimeAgo(issue.UpdatedOn) }},
+}
+
+// parseIssueColumns resolves a --columns value, falling back to the config
+// and then to the built-in set. "cf:Name" or "cf:ID" selects a custom field.
+func parseIssueColumns(value string, configured []string) ([]issueColumn, error) {
+	names := splitComma(value)
+	if len(names) == 0 {
+		names = configured
+	}
+	if len(names) == 0 {
+		names = defaultIssueColumns
+	}
+	columns := make([]issueColumn, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if field, ok := strings.CutPrefix(name, "cf:"); ok && strings.TrimSpace(field) != "" {
+			columns = append(columns, customFieldColumn(strings.TrimSpace(field)))
+			continue
+		}
+		column, ok := issueColumns[strings.ToLower(name)]
+		if !ok {
+			known := make([]string, 0, len(issueColumns))
+			for key := range issueColumns {
+				known = append(known, key)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown column %q (use %s or cf:<name>)", name, strings.Join(known, ", "))
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func customFieldColumn(field string) issueColumn {
+	id, err := strconv.Atoi(field)
+	byID := err == nil
+	matches := func(custom api.CustomField) bool {
+		return byID && custom.ID == id || !byID && foldName(custom.Name) == foldName(field)
+	}
+	return issueColumn{
+		header:   field,
+		flexible: true,
+		value: func(issue api.Issue) string {
+			for _, custom := range issue.CustomFields {
+				if matches(custom) {
+					return custom.String()
+				}
+			}
+			return ""
+		},
+		customField: matches,
+	}
+}
+
+// warnUnknownCustomFields warns about cf: columns that none of issues has,
+// which is most likely a typo in the field name.
+func warnUnknownCustomFields(columns []issueColumn, issues []api.Issue) {
+	if len(issues) == 0 {
+		return
+	}
+	for _, column := range columns {
+		if column.customField == nil {
+			continue
+		}
+		found := false
+		for _, issue := range issues {
+			for _, custom := range issue.CustomFields {
+				found = found || column.customField(custom)
+			}
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "warning: no issue has the custom field %q (--columns cf:%s)\n", column.header, column.header)
+		}
+	}
+}
+
+// formatHours prints hours without trailing zeros; 0 is shown as empty.
+func formatHours(hours float64) string {
+	if hours == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(hours, 'f', -1, 64)
+}
+
+// terminalWidth is the width to fit tables into when stdout is a terminal:
+// $COLUMNS, else the width of the terminal. It is 0 (no limit) when stdout
+// is piped or redirected, even with $COLUMNS exported by the shell.
+func terminalWidth() int {
+	if !stdoutIsTerminal() {
+		return 0
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	width, _ := ttySize(os.Stdout.Fd())
+	return width
+}
+
+// terminalHeight is $LINES or the height of the terminal on stdout, else 0.
+func terminalHeight() int {
+	if !stdoutIsTerminal() {
+		return 0
+	}
+	if height, err := strconv.Atoi(os.Getenv("LINES")); err == nil && height > 0 {
+		return height
+	}
+	_, height := ttySize(os.Stdout.Fd())
+	return height
+}
+
+// fitTable shrinks the widest flexible column, one character at a time,
+// until the table fits into width or no flexible column can shrink further.
+func fitTable(doc *document, width int) {
+	if width <= 0 || len(doc.flexible) != len(doc.columns) {
+		return
+	}
+	widths := make([]int, len(doc.columns))
+	for i, header := range doc.columns {
+		widths[i] = utf8.RuneCountInString(header)
+	}
+	for _, row := range doc.rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	total := 2 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+
+	for total > width {
+		widest := -1
+		for i, w := range widths {
+			floor := max(minColumnWidth, utf8.RuneCountInString(doc.columns[i]))
+			if doc.flexible[i] && w > floor && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+
+	for _, row := range doc.rows {
+		for i, cell := range row {
+			if doc.flexible[i] {
+				row[i] = truncate(widths[i], cell)
+			}
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Easy8Com/easy8-cli/internal/config"
+)
+
+// command is a node of the command tree: a group with subcommands or a leaf.
+// Leaves register their flags in setup, which returns the action to run once
+// the command line is parsed and the config is loaded.
+type command struct {
+	name     string
+	args     string // synopsis of the positional arguments, e.g. "<id>"
+	minArgs  int
+	maxArgs  int // -1 for any number
+	summary  string
+	about    []string
+	examples []string
+	commands []*command
+	setup    func(fs *flag.FlagSet, inv *invocation) func() int
+}
+
+// invocation is the state an action works with. ctx, cfg and args are only
+// set after parsing, so setup must read them from the returned action; ctx
+// is cancelled by a signal or by --timeout.
+// jsonErrors is set while JSON output is selected (globally or by the
+// command); errors are then printed as a JSON envelope on stderr.
+type invocation struct {
+	ctx        context.Context
+	cfg        config.Config
+	globals    *globalOptions
+	args       []string
+	jsonErrors bool
+}
+
+var rootCommand = &command{
+	name:     "easy8",
+	commands: []*command{issueCommand, authCommand, cacheCommand, devCommand},
+}
+
+func (c *command) find(name string) *command {
+	for _, sub := range c.commands {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (c *command) names() []string {
+	names := make([]string, len(c.commands))
+	for i, sub := range c.commands {
+		names[i] = sub.name
+	}
+	return names
+}
+
+// resolve walks args down the command tree. Global flags may appear between
+// the command names; help and --help print the usage of the group reached.
+func resolve(inv *invocation, args []string, globalFlags *flag.FlagSet) (cmd *command, path, rest []string, code int, done bool) {
+	cmd = rootCommand
+	for cmd.commands != nil {
+		if err := globalFlags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				printGroupUsage(cmd, path)
+				return nil, nil, nil, 0, true
+			}
+			return nil, nil, nil, inv.flagError(path, globalFlags, err), true
+		}
+		args = globalFlags.Args()
+		if len(args) == 0 {
+			printGroupUsage(cmd, path)
+			return nil, nil, nil, exitUsage, true
+		}
+		if args[0] == "help" {
+			return helpCommand(inv, args[1:], path)
+		}
+		sub := cmd.find(args[0])
+		if sub == nil {
+			label := strings.Join(append([]string{"unknown"}, append(path, "command")...), " ")
+			if suggestion := closestName(args[0], cmd.names()); suggestion != "" {
+				args[0] += " (did you mean " + suggestion + "?)"
+			}
+			group := cmd
+			return nil, nil, nil, inv.unknownCommand(label, args[0], func() { printGroupUsage(group, path) }), true
+		}
+		cmd, path, args = sub, append(path, sub.name), args[1:]
+	}
+	return cmd, path, args, 0, false
+}
+
+// helpCommand is "easy8 [group] help [command...]".
+func helpCommand(inv *invocation, args, path []string) (*command, []string, []string, int, bool) {
+	cmd := rootCommand
+	for _, name := range path {
+		cmd = cmd.find(name)
+	}
+	for _, name := range args {
+		sub := cmd.find(name)
+		if sub == nil {
+			return nil, nil, nil, inv.usageError(fmt.Errorf("no help for unknown command %q", strings.Join(append(path, name), " "))), true
+		}
+		cmd, path = sub, append(path, name)
+	}
+	if cmd.commands != nil {
+		printGroupUsage(cmd, path)
+	} else {
+		printCommandUsage(cmd, path)
+	}
+	return nil, nil, nil, 0, true
+}
+
+// newCommandFlags builds the flag set of a leaf: its own flags, then the
+// global flags it does not redefine, so that global flags may follow the
+// command too.
+func newCommandFlags(cmd *command, path []string, inv *invocation, globalFlags *flag.FlagSet) (*flag.FlagSet, func() int) {
+	fs := flag.NewFlagSet("easy8 "+strings.Join(path, " "), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	action := cmd.setup(fs, inv)
+	globalFlags.VisitAll(func(f *flag.Flag) {
+		if fs.Lookup(f.Name) == nil {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	return fs, action
+}
+
+// parseArgs parses flags anywhere among the positional arguments; after
+// "--" everything is positional.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func checkArgs(cmd *command, path []string, args []string) error {
+	switch {
+	case len(args) < cmd.minArgs:
+		return fmt.Errorf("easy8 %s needs %s", strings.Join(path, " "), cmd.args)
+	case cmd.maxArgs >= 0 && len(args) > cmd.maxArgs:
+		if cmd.maxArgs == 0 {
+			return fmt.Errorf("easy8 %s takes no arguments, got %q", strings.Join(path, " "), args[0])
+		}
+		return fmt.Errorf("easy8 %s takes %s, got %q", strings.Join(path, " "), cmd.args, strings.Join(args, " "))
+	}
+	return nil
+}
+
+// flagError reports a flag parse error, suggesting the closest flag for an
+// unknown one.
+func (inv *invocation) flagError(path []string, fs *flag.FlagSet, err error) int {
+	where := strings.Join(append([]string{"easy8"}, path...), " ")
+	name, unknown := strings.CutPrefix(err.Error(), "flag provided but not defined: -")
+	if !unknown {
+		return inv.usageError(err)
+	}
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	message := fmt.Sprintf("unknown flag %s for %q", flagName(name), where)
+	if suggestion := closestName(name, names); suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %s?)", flagName(suggestion))
+	}
+	return inv.usageError(errors.New(message))
+}
+
+func flagName(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// closestName is the name with the fewest edits from input, or one that
+// input is a prefix of; "" when nothing is close.
+func closestName(input string, names []string) string {
+	input = strings.TrimLeft(input, "-")
+	best, bestDistance := "", maxEdits(input)+1
+	for _, name := range names {
+		distance := editDistance(input, name)
+		if len(input) > 1 && strings.HasPrefix(name, input) {
+			distance = min(distance, 1)
+		}
+		if distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+// leaves lists the leaf commands below c with their paths, in tree order.
+func (c *command) leaves(path []string) (leaves []*command, paths [][]string) {
+	for _, sub := range c.commands {
+		subPath := append(append([]string{}, path...), sub.name)
+		if sub.commands == nil {
+			leaves = append(leaves, sub)
+			paths = append(paths, subPath)
+			continue
+		}
+		nested, nestedPaths := sub.leaves(subPath)
+		leaves = append(leaves, nested...)
+		paths = append(paths, nestedPaths...)
+	}
+	return leaves, paths
+}
+
+func synopsis(cmd *command, path []string) string {
+	line := strings.Join(append([]string{"easy8"}, path...), " ")
+	if cmd.args != "" {
+		line += " " + cmd.args
+	}
+	return line + " [flags]"
+}
+
+// printUsage prints the top-level usage: every command and the global flags.
+func printUsage() {
+	lines := []string{"easy8-cli", "", "Usage:", "  easy8 <command> [flags] [arguments]", "", "Commands:"}
+	leaves, paths := rootCommand.leaves(nil)
+	rows := make([][2]string, len(leaves))
+	for i, leaf := range leaves {
+		rows[i] = [2]string{strings.TrimSpace(strings.Join(paths[i], " ") + " " + leaf.args), leaf.summary}
+	}
+	lines = append(lines, alignRows(rows)...)
+	lines = append(lines, "", "Global flags (before or after the command):")
+	var globals globalOptions
+	lines = append(lines, flagLines(newGlobalFlags(&globals))...)
+	lines = append(lines, "", "Use 'easy8 <command> --help' for the flags of a command.")
+	writeUsage(lines)
+}
+
+// printGroupUsage prints the commands of a group and their examples; the
+// root group is the top-level usage.
+func printGroupUsage(group *command, path []string) {
+	if len(path) == 0 {
+		printUsage()
+		return
+	}
+	lines := []string{"easy8 " + strings.Join(path, " "), "", "Usage:"}
+	leaves, paths := group.leaves(path)
+	var examples []string
+	for i, leaf := range leaves {
+		lines = append(lines, "  "+synopsis(leaf, paths[i]))
+		examples = append(examples, leaf.examples...)
+	}
+	if len(group.about) > 0 {
+		lines = append(append(lines, ""), group.about...)
+	}
+	if len(examples) > 0 {
+		lines = append(lines, "", "Examples:")
+		for _, example := range examples {
+			lines = append(lines, "  "+example)
+		}
+	}
+	writeUsage(lines)
+}
+
+// printCommandUsage prints the synopsis, flags and examples of a leaf.
+func printCommandUsage(cmd *command, path []string) {
+	lines := []string{"easy8 " + strings.Join(path, " ") + " - " + cmd.summary, "", "Usage:", "  " + synopsis(cmd, path)}
+	if len(cmd.about) > 0 {
+		lines = append(append(lines, ""), cmd.about...)
+	}
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	cmd.setup(fs, &invocation{globals: &globalOptions{}})
+	if flags := flagLines(fs); len(flags) > 0 {
+		lines = append(append(lines, "", "Flags:"), flags...)
+	}
+	if len(cmd.examples) > 0 {
+		lines = append(lines, "", "Examples:")
+		for _, example := range cmd.examples {
+			lines = append(lines, "  "+example)
+		}
+	}
+	lines = append(lines, "", "Global flags are accepted too; see 'easy8 --help'.")
+	writeUsage(lines)
+}
+
+// flagLines lists the flags of fs, with aliases (flags bound to the same
+// variable, such as -o and --output) on one line.
+func flagLines(fs *flag.FlagSet) []string {
+	type entry struct {
+		names []string
+		flag  *flag.Flag
+	}
+	var entries []*entry
+	byTarget := make(map[uintptr]*entry)
+	fs.VisitAll(func(f *flag.Flag) {
+		value := reflect.ValueOf(f.Value)
+		if value.Kind() == reflect.Pointer {
+			if existing, ok := byTarget[value.Pointer()]; ok {
+				existing.names = append(existing.names, f.Name)
+				return
+			}
+		}
+		e := &entry{names: []string{f.Name}, flag: f}
+		if value.Kind() == reflect.Pointer {
+			byTarget[value.Pointer()] = e
+		}
+		entries = append(entries, e)
+	})
+
+	rows := make([][2]string, 0, len(entries))
+	for _, e := range entries {
+		sort.Slice(e.names, func(a, b int) bool { return len(e.names[a]) < len(e.names[b]) })
+		names := make([]string, len(e.names))
+		for i, name := range e.names {
+			names[i] = flagName(name)
+		}
+		placeholder, usage := flag.UnquoteUsage(e.flag)
+		left := strings.Join(names, ", ")
+		if placeholder != "" {
+			left += " <" + placeholder + ">"
+		}
+		if def := e.flag.DefValue; def != "" && def != "0" && def != "0s" && def != "false" {
+			usage += fmt.Sprintf(" (default %s)", def)
+		}
+		rows = append(rows, [2]string{left, usage})
+	}
+	return alignRows(rows)
+}
+
+func alignRows(rows [][2]string) []string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row[0]))
+	}
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = strings.TrimRight("  "+row[0]+strings.Repeat(" ", width-len(row[0])+2)+row[1], " ")
+	}
+	return lines
+}
+
+func writeUsage(lines []string) {
+	for _, line := range lines {
+		fmt.Fprintln(os.Stderr, line)
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Easy8Com/easy8-cli/pkg/easy8/easy8test"
+)
+
+var devCommand = &command{
+	name: "dev",
+	about: []string{
+		"Runs an in-memory fake Easy8 API (issues, users, projects, statuses, trackers,",
+		"priorities, search) for local testing. Changes are lost when it stops.",
+		"Point the CLI at it with EASY8_BASE_URL=http://127.0.0.1:3000.",
+	},
+	commands: []*command{
+		{name: "mock-server", summary: "Run an in-memory fake Easy8 server for local testing", setup: setupDevMockServer, examples: []string{"easy8 dev mock-server --addr 127.0.0.1:3000"}},
+	},
+}
+
+func setupDevMockServer(fs *flag.FlagSet, inv *invocation) func() int {
+	addr := fs.String("addr", "127.0.0.1:3000", "Listen address")
+	fixturePath := fs.String("fixture", "", "JSON fixture to seed the server (default: built-in data)")
+
+	return func() int {
+		fixture := easy8test.DefaultFixture()
+		if *fixturePath != "" {
+			var err error
+			fixture, err = easy8test.LoadFixture(*fixturePath)
+			if err != nil {
+				return inv.usageError(fmt.Errorf("invalid --fixture: %w", err))
+			}
+		}
+
+		listener, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return inv.apiError(err)
+		}
+		server := &http.Server{Handler: easy8test.New(fixture), ReadHeaderTimeout: 10 * time.Second}
+		fmt.Fprintf(os.Stderr, "mock Easy8 server listening on http://%s (Ctrl-C to stop)\n", listener.Addr())
+
+		done := make(chan error, 1)
+		go func() { done <- server.Serve(listener) }()
+		select {
+		case err := <-done:
+			return inv.apiError(err)
+		case <-inv.ctx.Done():
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return inv.apiError(err)
+		}
+		return 0
+	}
+}
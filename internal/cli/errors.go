@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+func isJSONFormat(format string) bool {
+	return format == "json" || format == "jsonl"
+}
+
+// jsonOutputArgs reports whether args select JSON output. It is checked
+// before parsing, so that errors found while parsing (an unknown command or
+// flag) are JSON as well, wherever -o/--output/--json appear. As in
+// parsing, the last of them wins.
+func jsonOutputArgs(args []string) bool {
+	selected := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "o", "output":
+			if !hasValue && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			selected = isJSONFormat(value)
+		case "json":
+			if enabled, err := strconv.ParseBool(value); hasValue && err == nil && !enabled {
+				continue
+			}
+			selected = true
+		}
+	}
+	return selected
+}
+
+// Error kinds besides the api.ErrorKind values of HTTP errors.
+const (
+	errorKindUsage       = "usage"
+	errorKindConfig      = "config"
+	errorKindOther       = string(api.ErrorKindOther)
+	errorKindTimeout     = "timeout"
+	errorKindInterrupted = "interrupted"
+)
+
+// errorEnvelope is {"error": {...}}. Every field is always present:
+// status_code and url are null for errors without an HTTP response and
+// validation_errors is empty unless the server returned {"errors": [...]}.
+type errorEnvelope struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Kind             string   `json:"kind"`
+	Message          string   `json:"message"`
+	StatusCode       *int     `json:"status_code"`
+	URL              *string  `json:"url"`
+	ValidationErrors []string `json:"validation_errors"`
+}
+
+func writeErrorJSON(kind, message string, apiErr *api.APIError) {
+	detail := errorDetail{Kind: kind, Message: message, ValidationErrors: []string{}}
+	if apiErr != nil {
+		detail.StatusCode = &apiErr.StatusCode
+		if apiErr.URL != "" {
+			detail.URL = &apiErr.URL
+		}
+		if apiErr.Errors != nil {
+			detail.ValidationErrors = apiErr.Errors
+		}
+	}
+	encoder := json.NewEncoder(os.Stderr)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(errorEnvelope{Error: detail}); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", message)
+	}
+}
+
+func (inv *invocation) configError(err error) int {
+	if inv.jsonErrors {
+		writeErrorJSON(errorKindConfig, err.Error(), nil)
+	} else {
+		fmt.Fprintln(os.Stderr, "config error:", err)
+	}
+	return exitError
+}
+
+// unknownCommand reports an unknown (sub)command; the usage text is only
+// shown without JSON errors.
+func (inv *invocation) unknownCommand(label, name string, usage func()) int {
+	if inv.jsonErrors {
+		writeErrorJSON(errorKindUsage, label+": "+name, nil)
+		return exitUsage
+	}
+	fmt.Fprintln(os.Stderr, label+":", name)
+	usage()
+	return exitUsage
+}
+
+// note prints a remark such as a close name match on stderr. With JSON
+// errors stderr only carries error envelopes, so notes are left out.
+func (inv *invocation) note(line string) {
+	if !inv.jsonErrors {
+		fmt.Fprintln(os.Stderr, "note: "+line)
+	}
+}
+
+// outputError reports a failure to render the output, e.g. a --query
+// function applied to the wrong type.
+func (inv *invocation) outputError(label string, err error) int {
+	if inv.jsonErrors {
+		writeErrorJSON(errorKindOther, label+" error: "+err.Error(), nil)
+	} else {
+		fmt.Fprintln(os.Stderr, label+" error:", err)
+	}
+	return exitError
+}
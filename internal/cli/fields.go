@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+// jsonFlag is --json: alone it selects JSON output, with a value
+// (--json id,subject,status.name) it also selects the fields to keep.
+type jsonFlag struct {
+	format *string
+	fields *[]string
+}
+
+func (f jsonFlag) String() string {
+	return ""
+}
+
+func (f jsonFlag) Set(value string) error {
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		if enabled {
+			*f.format = "json"
+		}
+		return nil
+	}
+	if !fieldListPattern.MatchString(value) {
+		return fmt.Errorf("invalid field list %q (use e.g. id,subject,status.name)", value)
+	}
+	*f.format = "json"
+	*f.fields = splitComma(value)
+	return nil
+}
+
+func (f jsonFlag) IsBoolFlag() bool {
+	return true
+}
+
+var fieldListPattern = regexp.MustCompile(`^\s*[A-Za-z_]\w*(\.\w+)*(\s*,\s*[A-Za-z_]\w*(\.\w+)*)*\s*$`)
+
+// jsonFieldArgs rewrites "--json id,subject" to "--json=id,subject", since
+// the flag package only accepts a value for boolean flags after "=".
+func jsonFieldArgs(args []string) []string {
+	rewritten := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(rewritten, args[i:]...)
+		}
+		if (arg == "--json" || arg == "-json") && i+1 < len(args) && fieldListPattern.MatchString(args[i+1]) {
+			rewritten = append(rewritten, arg+"="+args[i+1])
+			i++
+			continue
+		}
+		rewritten = append(rewritten, arg)
+	}
+	return rewritten
+}
+
+// checkIssueFields rejects top-level names that are not fields of the
+// issue JSON, so a typo does not silently print nulls.
+func checkIssueFields(fields []string) error {
+	known := jsonFieldNames(reflect.TypeOf(api.Issue{}))
+	for _, path := range fields {
+		name, _, _ := strings.Cut(path, ".")
+		found := false
+		for _, field := range known {
+			found = found || field == name
+		}
+		if !found {
+			return fmt.Errorf("unknown JSON field %q (use %s)", name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// projectFields keeps only the given field paths of every record. The
+// projected records replace doc.key of the value, so the envelope (e.g.
+// {"issues": [...], "total_count": ...}) is the same as without fields.
+// Missing fields are null, so the shape of the output only depends on the
+// requested fields.
+func projectFields(doc document, fields []string) (document, error) {
+	projected := make([]any, 0, len(doc.records))
+	for _, record := range doc.records {
+		object, err := toOrdered(record)
+		if err != nil {
+			return doc, err
+		}
+		var result orderedObject
+		for _, path := range fields {
+			result = setPath(result, strings.Split(path, "."), lookupPath(object, strings.Split(path, ".")))
+		}
+		if result == nil {
+			result = orderedObject{}
+		}
+		projected = append(projected, result)
+	}
+	doc.records = projected
+	var items any = projected
+	if doc.single && len(projected) == 1 {
+		items = projected[0]
+	}
+	if doc.key == "" {
+		doc.value = items
+		return doc, nil
+	}
+	envelope, err := toOrdered(doc.value)
+	if err != nil {
+		return doc, err
+	}
+	object, ok := envelope.(orderedObject)
+	if !ok {
+		return doc, fmt.Errorf("no %q field to select from", doc.key)
+	}
+	doc.value = setPath(object, []string{doc.key}, items)
+	return doc, nil
+}
+
+func toOrdered(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeOrdered(decoder)
+}
+
+func lookupPath(value any, path []string) any {
+	for _, key := range path {
+		object, ok := value.(orderedObject)
+		if !ok {
+			return nil
+		}
+		value = object.get(key)
+	}
+	return value
+}
+
+func setPath(object orderedObject, path []string, value any) orderedObject {
+	for i, field := range object {
+		if field.key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			object[i].value = value
+		} else if nested, ok := field.value.(orderedObject); ok {
+			object[i].value = setPath(nested, path[1:], value)
+		}
+		return object
+	}
+	if len(path) > 1 {
+		value = setPath(nil, path[1:], value)
+	}
+	return append(object, orderedField{key: path[0], value: value})
+}
+
+func (o orderedObject) get(key string) any {
+	for _, field := range o {
+		if field.key == key {
+			return field.value
+		}
+	}
+	return nil
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// printQuery prints the result of --query over the JSON value of doc.
+// Strings are printed without quotes so they can be used in shell scripts.
+// With jsonl, a list result is printed one compact item per line.
+func (p printer) printQuery(doc document) int {
+	data, err := json.Marshal(doc.value)
+	if err != nil {
+		return p.inv.outputError("output", err)
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return p.inv.outputError("output", err)
+	}
+	result, err := p.query.Search(value)
+	if err != nil {
+		return p.inv.outputError("query", err)
+	}
+	if s, ok := result.(string); ok {
+		return p.flush([]byte(s + "\n"))
+	}
+	var b bytes.Buffer
+	if p.format == "jsonl" {
+		items, ok := result.([]any)
+		if !ok {
+			items = []any{result}
+		}
+		for _, item := range items {
+			if s, ok := item.(string); ok {
+				b.WriteString(s + "\n")
+			} else if err := json.NewEncoder(&b).Encode(item); err != nil {
+				return p.inv.outputError("output", err)
+			}
+		}
+		return p.flush(b.Bytes())
+	}
+	if err := writeJSON(&b, document{value: result}); err != nil {
+		return p.inv.outputError("output", err)
+	}
+	return p.flush(b.Bytes())
+}
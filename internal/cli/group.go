@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+// groupKey is an issue field that --group-by accepts.
+type groupKey struct {
+	name   string
+	header string
+	byName bool // order groups by name instead of by ID
+	ref    func(api.Issue) *api.NamedRef
+}
+
+var groupKeys = map[string]groupKey{
+	"status":   {header: "Status", ref: func(issue api.Issue) *api.NamedRef { return issue.Status }},
+	"priority": {header: "Priority", ref: func(issue api.Issue) *api.NamedRef { return issue.Priority }},
+	"tracker":  {header: "Tracker", ref: func(issue api.Issue) *api.NamedRef { return issue.Tracker }},
+	"project":  {header: "Project", byName: true, ref: func(issue api.Issue) *api.NamedRef { return issue.Project }},
+	"assignee": {header: "Assignee", byName: true, ref: func(issue api.Issue) *api.NamedRef { return issue.AssignedTo }},
+	"author":   {header: "Author", byName: true, ref: func(issue api.Issue) *api.NamedRef { return issue.Author }},
+}
+
+func addGroupFlags(fs *flag.FlagSet, out *outputFlags) {
+	fs.StringVar(&out.groupBy, "group-by", "", "Group the issues by status, assignee, project, priority, tracker or author (one or two, comma-separated); with --all every page")
+	fs.BoolVar(&out.sums, "sum", false, "Add estimated and spent hour totals to each group")
+	fs.BoolVar(&out.summary, "summary", false, "Print only the per-group counts (a matrix for two --group-by fields)")
+}
+
+type grouping struct {
+	keys    []groupKey
+	sums    bool
+	summary bool
+}
+
+func parseGrouping(value string, sums, summary bool) (*grouping, error) {
+	names := splitComma(value)
+	if len(names) == 0 {
+		if sums || summary {
+			return nil, fmt.Errorf("--sum and --summary need --group-by")
+		}
+		return nil, nil
+	}
+	if len(names) > 2 {
+		return nil, fmt.Errorf("--group-by takes one or two fields, got %d", len(names))
+	}
+	g := &grouping{sums: sums, summary: summary}
+	for _, name := range names {
+		key, ok := groupKeys[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown group %q (use assignee, author, priority, project, status or tracker)", name)
+		}
+		key.name = strings.ToLower(name)
+		g.keys = append(g.keys, key)
+	}
+	return g, nil
+}
+
+type issueGroup struct {
+	refs      []*api.NamedRef
+	issues    []api.Issue
+	estimated float64
+	spent     float64
+}
+
+// group splits issues by the grouping keys, keeping the order of issues
+// within a group. Groups are ordered by ID (statuses, priorities, trackers)
+// or name, with issues lacking the field last.
+func (g *grouping) group(issues []api.Issue) []*issueGroup {
+	index := make(map[string]*issueGroup)
+	var groups []*issueGroup
+	for _, issue := range issues {
+		refs := make([]*api.NamedRef, len(g.keys))
+		var id strings.Builder
+		for i, key := range g.keys {
+			refs[i] = key.ref(issue)
+			id.WriteString(refKey(refs[i]) + "|")
+		}
+		group, ok := index[id.String()]
+		if !ok {
+			group = &issueGroup{refs: refs}
+			index[id.String()] = group
+			groups = append(groups, group)
+		}
+		group.issues = append(group.issues, issue)
+		group.estimated += issue.EstimatedHours
+		group.spent += issue.SpentHours
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		for i, key := range g.keys {
+			if c := compareRefs(key, groups[a].refs[i], groups[b].refs[i]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return groups
+}
+
+func compareRefs(key groupKey, a, b *api.NamedRef) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	if key.byName {
+		if c := strings.Compare(foldName(a.Name), foldName(b.Name)); c != 0 {
+			return c
+		}
+	}
+	return a.ID - b.ID
+}
+
+func refLabel(ref *api.NamedRef) string {
+	if ref == nil {
+		return "(none)"
+	}
+	return ref.Name
+}
+
+func hoursCell(hours float64) string {
+	return strconv.FormatFloat(hours, 'f', -1, 64)
+}
+
+// object is the JSON form of a group: the group fields, the count, the hour
+// totals with --sum and, unless summarizing, the issues.
+func (g *grouping) object(group *issueGroup) orderedObject {
+	object := orderedObject{}
+	for i, key := range g.keys {
+		var ref any
+		if group.refs[i] != nil {
+			ref = group.refs[i]
+		}
+		object = append(object, orderedField{key: key.name, value: ref})
+	}
+	object = append(object, g.totals(len(group.issues), group.estimated, group.spent)...)
+	if !g.summary {
+		object = append(object, orderedField{key: "issues", value: group.issues})
+	}
+	return object
+}
+
+func (g *grouping) totals(count int, estimated, spent float64) orderedObject {
+	object := orderedObject{{key: "count", value: count}}
+	if g.sums {
+		object = append(object, orderedField{key: "estimated_hours", value: estimated}, orderedField{key: "spent_hours", value: spent})
+	}
+	return object
+}
+
+// groupDocument is the document of a grouped issue list. JSON output is
+// {"groups": [...], "total": {...}, "total_count": n}; the table format
+// shows a section per group and the other tabular formats prepend the group
+// columns. total_count is the server's count, which is more than the total
+// when paging was interrupted.
+func (p printer) groupDocument(issues []api.Issue, totalCount int) document {
+	g := p.group
+	groups := g.group(issues)
+	doc := document{records: make([]any, 0, len(groups))}
+	var estimated, spent float64
+	for _, group := range groups {
+		doc.records = append(doc.records, g.object(group))
+		estimated += group.estimated
+		spent += group.spent
+	}
+	doc.value = orderedObject{
+		{key: "groups", value: doc.records},
+		{key: "total", value: g.totals(len(issues), estimated, spent)},
+		{key: "total_count", value: totalCount},
+	}
+	if g.summary {
+		if len(g.keys) == 2 {
+			g.matrix(&doc, groups)
+		} else {
+			g.counts(&doc, groups, len(issues), estimated, spent)
+		}
+		return doc
+	}
+
+	table := p.table()
+	if !table {
+		for _, key := range g.keys {
+			doc.columns = append(doc.columns, key.header)
+			doc.flexible = append(doc.flexible, false)
+		}
+	}
+	for _, column := range p.columns {
+		doc.columns = append(doc.columns, column.header)
+		doc.flexible = append(doc.flexible, column.flexible)
+	}
+	for _, group := range groups {
+		labels := make([]string, len(group.refs))
+		for i, ref := range group.refs {
+			labels[i] = refLabel(ref)
+		}
+		rows, colors := p.issueRows(group.issues)
+		if table {
+			doc.sections = append(doc.sections, section{title: g.title(labels, group), start: len(doc.rows), end: len(doc.rows) + len(rows)})
+			doc.rows = append(doc.rows, rows...)
+			if colors != nil {
+				doc.colors = append(doc.colors, colors...)
+			}
+			continue
+		}
+		for _, row := range rows {
+			doc.rows = append(doc.rows, append(append([]string{}, labels...), row...))
+		}
+	}
+	if table && p.color && doc.colors == nil {
+		doc.colors = [][]string{}
+	}
+	return doc
+}
+
+func (g *grouping) title(labels []string, group *issueGroup) string {
+	count := fmt.Sprintf("%d issues", len(group.issues))
+	if len(group.issues) == 1 {
+		count = "1 issue"
+	}
+	if g.sums {
+		count += fmt.Sprintf(", %sh estimated, %sh spent", hoursCell(group.estimated), hoursCell(group.spent))
+	}
+	return strings.Join(labels, " / ") + " (" + count + ")"
+}
+
+func (g *grouping) counts(doc *document, groups []*issueGroup, total int, estimated, spent float64) {
+	doc.columns = []string{g.keys[0].header, "Issues"}
+	if g.sums {
+		doc.columns = append(doc.columns, "Estimated", "Spent")
+	}
+	row := func(label string, count int, estimated, spent float64) []string {
+		cells := []string{label, strconv.Itoa(count)}
+		if g.sums {
+			cells = append(cells, hoursCell(estimated), hoursCell(spent))
+		}
+		return cells
+	}
+	for _, group := range groups {
+		doc.rows = append(doc.rows, row(refLabel(group.refs[0]), len(group.issues), group.estimated, group.spent))
+	}
+	doc.rows = append(doc.rows, row("Total", total, estimated, spent))
+}
+
+// matrix lays out the counts of two group fields, the first as rows and the
+// second as columns, with totals on both edges. Hours are only in the JSON.
+func (g *grouping) matrix(doc *document, groups []*issueGroup) {
+	var rows, columns []*api.NamedRef
+	seenRow, seenColumn := make(map[string]bool), make(map[string]bool)
+	counts := make(map[string]int)
+	for _, group := range groups {
+		rowID, columnID := refKey(group.refs[0]), refKey(group.refs[1])
+		if !seenRow[rowID] {
+			seenRow[rowID] = true
+			rows = append(rows, group.refs[0])
+		}
+		if !seenColumn[columnID] {
+			seenColumn[columnID] = true
+			columns = append(columns, group.refs[1])
+		}
+		counts[rowID+"|"+columnID] += len(group.issues)
+	}
+	sort.SliceStable(columns, func(a, b int) bool {
+		return compareRefs(g.keys[1], columns[a], columns[b]) < 0
+	})
+
+	doc.columns = []string{g.keys[0].header + " / " + g.keys[1].header}
+	for _, column := range columns {
+		doc.columns = append(doc.columns, refLabel(column))
+	}
+	doc.columns = append(doc.columns, "Total")
+	columnTotals := make([]int, len(columns))
+	total := 0
+	for _, row := range rows {
+		cells := []string{refLabel(row)}
+		rowTotal := 0
+		for i, column := range columns {
+			count := counts[refKey(row)+"|"+refKey(column)]
+			cells = append(cells, strconv.Itoa(count))
+			rowTotal += count
+			columnTotals[i] += count
+		}
+		total += rowTotal
+		doc.rows = append(doc.rows, append(cells, strconv.Itoa(rowTotal)))
+	}
+	cells := []string{"Total"}
+	for _, count := range columnTotals {
+		cells = append(cells, strconv.Itoa(count))
+	}
+	doc.rows = append(doc.rows, append(cells, strconv.Itoa(total)))
+}
+
+func refKey(ref *api.NamedRef) string {
+	if ref == nil {
+		return "-"
+	}
+	return strconv.Itoa(ref.ID)
+}
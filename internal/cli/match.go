@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	scoreExact      = 100
+	scoreFolded     = 90
+	scorePrefix     = 70
+	scoreWordPrefix = 60
+	scoreSubstring  = 50
+	scoreEdit       = 40
+
+	maxSuggestions = 5
+)
+
+type candidate struct {
+	ID    int
+	Label string
+	Keys  []string
+}
+
+type rankedCandidate struct {
+	candidate
+	score int
+}
+
+type ambiguousError struct {
+	label      string
+	name       string
+	candidates []candidate
+}
+
+func (err ambiguousError) Error() string {
+	return fmt.Sprintf("%s matches multiple entries: %s", err.label, err.name) + formatCandidates(err.candidates)
+}
+
+// matchNotes collects a note for every close match used in place of an exact
+// one; the command prints them. Lookups may add notes concurrently.
+type matchNotes struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (n *matchNotes) add(line string) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.lines = append(n.lines, line)
+}
+
+// pickCandidate resolves name against items. An exact (case-insensitive)
+// match wins silently. Otherwise the single best-ranked close match is used
+// and noted in matched; ties are ambiguous and list the tied candidates.
+func pickCandidate(items []candidate, name, label string, matched *matchNotes) (candidate, error) {
+	ranked := rankCandidates(items, name)
+	if len(ranked) == 0 {
+		return candidate{}, notFoundError{label: label, name: name}
+	}
+
+	best := ranked[0].score
+	var tied []candidate
+	for _, item := range ranked {
+		if item.score == best {
+			tied = append(tied, item.candidate)
+		}
+	}
+	if best == scoreExact {
+		if len(tied) > 1 {
+			return candidate{}, ambiguousError{label: label, name: name, candidates: tied}
+		}
+		return tied[0], nil
+	}
+	if len(tied) == 1 {
+		matched.add(fmt.Sprintf("%s %q matched %q (id %d)", label, name, tied[0].Label, tied[0].ID))
+		return tied[0], nil
+	}
+	return candidate{}, ambiguousError{label: label, name: name, candidates: suggestions(ranked[:len(tied)])}
+}
+
+// pickExactCandidate is pickCandidate without close matches, for names where
+// a guess is not acceptable: only an exact or folded match resolves and
+// anything else fails with the closest candidates.
+func pickExactCandidate(items []candidate, name, label string) (candidate, error) {
+	ranked := rankCandidates(items, name)
+	if len(ranked) == 0 || ranked[0].score < scoreFolded {
+		return candidate{}, notFoundError{label: label, name: name, candidates: suggestions(ranked)}
+	}
+	var tied []candidate
+	for _, item := range ranked {
+		if item.score == ranked[0].score {
+			tied = append(tied, item.candidate)
+		}
+	}
+	if len(tied) > 1 {
+		return candidate{}, ambiguousError{label: label, name: name, candidates: tied}
+	}
+	return tied[0], nil
+}
+
+func suggestions(ranked []rankedCandidate) []candidate {
+	result := make([]candidate, 0, maxSuggestions)
+	for _, item := range ranked {
+		if len(result) == maxSuggestions {
+			break
+		}
+		result = append(result, item.candidate)
+	}
+	return result
+}
+
+func rankCandidates(items []candidate, name string) []rankedCandidate {
+	var ranked []rankedCandidate
+	for _, item := range items {
+		score := 0
+		for _, key := range item.Keys {
+			if s := scoreName(name, key); s > score {
+				score = s
+			}
+		}
+		if score > 0 {
+			ranked = append(ranked, rankedCandidate{candidate: item, score: score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].Label < ranked[j].Label
+	})
+	return ranked
+}
+
+func scoreName(input, key string) int {
+	if strings.TrimSpace(key) == "" {
+		return 0
+	}
+	if normalizeName(key) == normalizeName(input) {
+		return scoreExact
+	}
+	needle := foldName(input)
+	value := foldName(key)
+	if needle == "" {
+		return 0
+	}
+	switch {
+	case needle == value:
+		return scoreFolded
+	case strings.HasPrefix(value, needle):
+		return scorePrefix
+	case hasWordPrefix(value, needle):
+		return scoreWordPrefix
+	case strings.Contains(value, needle):
+		return scoreSubstring
+	}
+	distance := editDistance(needle, value)
+	if distance <= maxEdits(needle) {
+		return scoreEdit - distance
+	}
+	return 0
+}
+
+func hasWordPrefix(value, needle string) bool {
+	for _, word := range strings.Fields(value) {
+		if strings.HasPrefix(word, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func maxEdits(value string) int {
+	edits := utf8.RuneCountInString(value) / 4
+	if edits < 1 {
+		return 1
+	}
+	if edits > 3 {
+		return 3
+	}
+	return edits
+}
+
+// editDistance is the optimal string alignment distance: insertions,
+// deletions, substitutions and transpositions of adjacent runes.
+func editDistance(a, b string) int {
+	left := []rune(a)
+	right := []rune(b)
+	rows := make([][]int, len(left)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(right)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(left); i++ {
+		for j := 1; j <= len(right); j++ {
+			cost := 1
+			if left[i-1] == right[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && left[i-1] == right[j-2] && left[i-2] == right[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(left)][len(right)]
+}
+
+// foldName lowercases, strips diacritics and collapses whitespace so that
+// "Novák" and "novak" compare equal.
+func foldName(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if folded, ok := diacritics[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+var diacritics = map[rune]string{
+	'á': "a", 'à': "a", 'â': "a", 'ä': "a", 'ã': "a", 'å': "a", 'ą': "a", 'ă': "a",
+	'č': "c", 'ć': "c", 'ç': "c",
+	'ď': "d", 'đ': "d",
+	'é': "e", 'è': "e", 'ê': "e", 'ë': "e", 'ě': "e", 'ę': "e",
+	'í': "i", 'ì': "i", 'î': "i", 'ï': "i",
+	'ĺ': "l", 'ľ': "l", 'ł': "l",
+	'ň': "n", 'ń': "n", 'ñ': "n",
+	'ó': "o", 'ò': "o", 'ô': "o", 'ö': "o", 'õ': "o", 'ő': "o", 'ø': "o",
+	'ř': "r", 'ŕ': "r",
+	'š': "s", 'ś': "s", 'ş': "s",
+	'ť': "t", 'ţ': "t",
+	'ú': "u", 'ù': "u", 'û': "u", 'ü': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y",
+	'ž': "z", 'ź': "z", 'ż': "z",
+	'ß': "ss",
+}
+
+func formatCandidates(candidates []candidate) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	lines := []string{"", "did you mean:"}
+	for _, item := range candidates {
+		lines = append(lines, fmt.Sprintf("  - %s (id %d)", item.Label, item.ID))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,50 +1,610 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"text/tabwriter"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
 
-	"easy8-cli/internal/api"
+	"github.com/Easy8Com/easy8-cli/internal/api"
+	"github.com/Easy8Com/easy8-cli/internal/config"
+	"github.com/Easy8Com/easy8-cli/internal/query"
 )
 
-func outputJSON(value any) int {
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(value); err != nil {
-		fmt.Fprintln(os.Stderr, "output error:", err)
-		return 1
+// document is the result of a command. json and yaml print value, jsonl
+// prints one record per line, and the tabular formats print columns and rows.
+// notes are extra lines shown above the table format only; flexible marks
+// the columns the table format may truncate to fit the terminal and colors
+// holds the color of every table cell. single is set when value describes
+// one item rather than a list. sections split the table rows under titles.
+// key is the field of value that holds the records, e.g. "issues".
+type document struct {
+	value    any
+	records  []any
+	single   bool
+	key      string
+	columns  []string
+	flexible []bool
+	rows     [][]string
+	colors   [][]string
+	sections []section
+	notes    []string // printed above the table
+	footer   []string // printed below the table
+}
+
+type section struct {
+	title      string
+	start, end int
+}
+
+type formatter func(w io.Writer, doc document) error
+
+var formatters = map[string]formatter{
+	"table":    writeTable,
+	"json":     writeJSON,
+	"jsonl":    writeJSONL,
+	"csv":      writeCSV(','),
+	"tsv":      writeCSV('\t'),
+	"yaml":     writeYAML,
+	"markdown": writeMarkdown,
+}
+
+var formatNames = []string{"table", "json", "jsonl", "csv", "tsv", "yaml", "markdown"}
+
+func checkFormat(format string) error {
+	if _, ok := formatters[format]; !ok {
+		return fmt.Errorf("unknown output format %q (use %s)", format, strings.Join(formatNames, ", "))
+	}
+	return nil
+}
+
+type outputFlags struct {
+	format       string
+	template     string
+	templateFile string
+	wide         bool
+	columns      string
+	issues       bool
+	fields       []string
+	query        string
+	groupBy      string
+	sums         bool
+	summary      bool
+	inv          *invocation
+}
+
+// addOutputFlags registers --output/-o, the template flags and --query,
+// defaulting to the global choices, and --json as an alias for --output json
+// that optionally selects fields.
+func addOutputFlags(fs *flag.FlagSet, inv *invocation) *outputFlags {
+	globals := inv.globals
+	out := &outputFlags{inv: inv}
+	usage := "Output `format`: " + strings.Join(formatNames, ", ")
+	fs.StringVar(&out.format, "output", globals.output, usage)
+	fs.StringVar(&out.format, "o", globals.output, usage)
+	fs.Var(jsonFlag{&out.format, &out.fields}, "json", "JSON output (same as --output json); --json id,subject,status.name keeps only those fields")
+	queryUsage := "JMESPath `expression` applied to the JSON output, e.g. 'issues[*].id'"
+	fs.StringVar(&out.query, "query", globals.query, queryUsage)
+	fs.StringVar(&out.query, "jq", globals.query, queryUsage)
+	fs.StringVar(&out.template, "template", globals.template, "Go `template` applied to each item, or the name of a template from the config")
+	fs.StringVar(&out.templateFile, "template-file", globals.templateFile, "Read the Go template applied to each item from a `file`")
+	fs.BoolVar(&out.wide, "wide", globals.wide, "Do not truncate table columns to the terminal width")
+	return out
+}
+
+// addIssueOutputFlags adds --columns to the output flags.
+func addIssueOutputFlags(fs *flag.FlagSet, inv *invocation) *outputFlags {
+	out := addOutputFlags(fs, inv)
+	fs.StringVar(&out.columns, "columns", "", "Issue columns, e.g. id,subject,priority,due_date,project,cf:Customer")
+	out.issues = true
+	return out
+}
+
+// printer checks the output flags; commands call it before making requests.
+func (out *outputFlags) printer(cfg config.Config) (printer, error) {
+	if err := checkFormat(out.format); err != nil {
+		return printer{}, err
+	}
+	colored := useColor(cfg.Color)
+	tmpl, err := parseTemplate(out.template, out.templateFile, cfg.Templates, colored)
+	if err != nil {
+		return printer{}, err
+	}
+	var columns []issueColumn
+	if out.issues {
+		if columns, err = parseIssueColumns(out.columns, cfg.Columns); err != nil {
+			return printer{}, err
+		}
+		if err := checkIssueFields(out.fields); err != nil {
+			return printer{}, err
+		}
+	}
+	var q *query.Query
+	if out.query != "" {
+		if q, err = query.Compile(out.query); err != nil {
+			return printer{}, fmt.Errorf("invalid --query: %w", err)
+		}
+	}
+	if tmpl != nil && (q != nil || out.fields != nil) {
+		return printer{}, fmt.Errorf("--template cannot be combined with --query or --json fields")
+	}
+	group, err := parseGrouping(out.groupBy, out.sums, out.summary)
+	if err != nil {
+		return printer{}, err
+	}
+	if group != nil && (tmpl != nil || out.fields != nil) {
+		return printer{}, fmt.Errorf("--group-by cannot be combined with --template or --json fields")
+	}
+	if out.fields != nil && !isJSONFormat(out.format) {
+		return printer{}, fmt.Errorf("--json fields cannot be combined with --output %s", out.format)
+	}
+	stdout := out.inv.globals.stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	pager := ""
+	if stdout == io.Writer(os.Stdout) {
+		pager = pagerCommand(out.inv.globals.noPager)
+	}
+	return printer{inv: out.inv, out: stdout, pager: pager, format: out.format, template: tmpl, wide: out.wide, color: colored, columns: columns, group: group, fields: out.fields, query: q}, nil
+}
+
+// printer renders documents to out, paging output taller than the terminal
+// through the pager command when it is set.
+type printer struct {
+	inv      *invocation
+	out      io.Writer
+	pager    string
+	format   string
+	template *template.Template
+	wide     bool
+	color    bool
+	columns  []issueColumn
+	styles   issueStyles
+	group    *grouping
+	fields   []string
+	query    *query.Query
+}
+
+// table reports whether the output is the human-readable table.
+func (p printer) table() bool {
+	return p.format == "table" && p.template == nil && p.query == nil
+}
+
+// loadStyles fetches what the colored issue table needs (statuses and
+// priorities, usually from the cache); it does nothing without color.
+func (p *printer) loadStyles(ctx context.Context, lookups lookupSource) {
+	if p.color && p.table() {
+		p.styles = loadIssueStyles(ctx, lookups)
+	}
+}
+
+func (p printer) print(doc document) int {
+	if p.fields != nil {
+		projected, err := projectFields(doc, p.fields)
+		if err != nil {
+			return p.inv.outputError("output", err)
+		}
+		doc = projected
+	}
+	if p.query != nil {
+		return p.printQuery(doc)
+	}
+	var b bytes.Buffer
+	if p.template == nil {
+		if p.table() && !p.wide {
+			fitTable(&doc, terminalWidth())
+		}
+		write, ok := formatters[p.format]
+		if !ok {
+			return p.inv.usageError(checkFormat(p.format))
+		}
+		if err := write(&b, doc); err != nil {
+			return p.inv.outputError("output", err)
+		}
+		return p.flush(b.Bytes())
+	}
+	for _, record := range doc.records {
+		if err := p.template.Execute(&b, record); err != nil {
+			return p.inv.outputError("template", err)
+		}
+		if b.Len() > 0 && b.Bytes()[b.Len()-1] != '\n' {
+			b.WriteByte('\n')
+		}
+	}
+	return p.flush(b.Bytes())
+}
+
+// flush writes rendered output, through the pager when it is too long.
+func (p printer) flush(data []byte) int {
+	if err := writePaged(p.out, p.pager, data); err != nil {
+		return p.inv.outputError("output", err)
 	}
 	return 0
 }
 
-func outputIssues(issues []api.Issue) int {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tSubject\tStatus\tAssignee\tUpdated")
+// issueDocument builds the document for issue commands. The table format
+// shows relative times and, with color on, colored cells.
+func (p printer) issueDocument(value any, issues []api.Issue) document {
+	if p.group != nil {
+		totalCount := len(issues)
+		if list, ok := value.(api.IssueListResponse); ok {
+			totalCount = list.TotalCount
+		}
+		return p.groupDocument(issues, totalCount)
+	}
+	doc := document{
+		value:   value,
+		records: make([]any, 0, len(issues)),
+	}
+	_, doc.single = value.(api.IssueResponse)
+	doc.key = "issues"
+	if doc.single {
+		doc.key = "issue"
+	}
+	for _, column := range p.columns {
+		doc.columns = append(doc.columns, column.header)
+		doc.flexible = append(doc.flexible, column.flexible)
+	}
 	for _, issue := range issues {
-		status := nameOrEmpty(issue.Status)
-		assignee := nameOrEmpty(issue.AssignedTo)
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", issue.ID, issue.Subject, status, assignee, issue.UpdatedOn)
+		doc.records = append(doc.records, issue)
 	}
-	if err := w.Flush(); err != nil {
-		fmt.Fprintln(os.Stderr, "output error:", err)
-		return 1
+	doc.rows, doc.colors = p.issueRows(issues)
+	if p.fields == nil && p.query == nil && p.template == nil {
+		warnUnknownCustomFields(p.columns, issues)
 	}
-	return 0
+	if doc.single && len(issues) == 1 {
+		doc.footer = issueFooter(issues[0])
+	}
+	return doc
 }
 
-func outputSearch(results []api.SearchResult) int {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tType\tTitle\tURL")
-	for _, result := range results {
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", result.ID, result.Type, result.Title, result.URL)
+// issueFooter lists the attachments and journals of an issue shown with
+// --include attachments,journals below its table row.
+func issueFooter(issue api.Issue) []string {
+	var lines []string
+	if len(issue.Attachments) > 0 {
+		lines = append(lines, "", "Attachments:")
+		for _, attachment := range issue.Attachments {
+			line := fmt.Sprintf("  %s (%d bytes", attachment.Filename, attachment.Filesize)
+			if attachment.Author != nil {
+				line += ", " + attachment.Author.Name
+			}
+			lines = append(lines, line+")")
+		}
 	}
-	if err := w.Flush(); err != nil {
-		fmt.Fprintln(os.Stderr, "output error:", err)
-		return 1
+	if len(issue.Journals) > 0 {
+		lines = append(lines, "", "History:")
+		for _, journal := range issue.Journals {
+			header := "  " + journal.CreatedOn
+			if journal.User != nil {
+				header += "  " + journal.User.Name
+			}
+			lines = append(lines, header)
+			for _, detail := range journal.Details {
+				lines = append(lines, fmt.Sprintf("    %s: %s -> %s", detail.Name, orDash(detail.OldValue), orDash(detail.NewValue)))
+			}
+			for _, note := range strings.Split(journal.Notes, "\n") {
+				if note != "" {
+					lines = append(lines, "    "+note)
+				}
+			}
+		}
 	}
-	return 0
+	return lines
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// issueRows renders the cells of the selected columns; colors is nil
+// unless the colored table is printed.
+func (p printer) issueRows(issues []api.Issue) (rows, colors [][]string) {
+	table := p.table()
+	for _, issue := range issues {
+		row := make([]string, len(p.columns))
+		rowColors := make([]string, len(p.columns))
+		for i, column := range p.columns {
+			row[i] = column.value(issue)
+			if table && column.display != nil {
+				row[i] = column.display(issue)
+			}
+			if table && p.color && column.style != nil {
+				rowColors[i] = column.style(p.styles, issue)
+			}
+		}
+		rows = append(rows, row)
+		if table && p.color {
+			colors = append(colors, rowColors)
+		}
+	}
+	if table && p.color && colors == nil {
+		colors = [][]string{}
+	}
+	return rows, colors
+}
+
+func writeTable(w io.Writer, doc document) error {
+	for _, note := range doc.notes {
+		if _, err := fmt.Fprintln(w, note); err != nil {
+			return err
+		}
+	}
+	if doc.columns == nil {
+		return nil
+	}
+	widths := make([]int, len(doc.columns))
+	for i, header := range doc.columns {
+		widths[i] = utf8.RuneCountInString(header)
+	}
+	for _, row := range doc.rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	// Columns are padded by hand rather than with text/tabwriter so that
+	// color escapes do not count towards the width.
+	var b strings.Builder
+	line := func(cells, colors []string) {
+		for i, cell := range cells {
+			text := cell
+			if colors != nil && colors[i] != "" {
+				text = paint(colors[i], cell)
+			}
+			b.WriteString(text)
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		b.WriteByte('\n')
+	}
+	var headerColors []string
+	if doc.colors != nil {
+		headerColors = make([]string, len(doc.columns))
+		for i := range headerColors {
+			headerColors[i] = "bold"
+		}
+	}
+	line(doc.columns, headerColors)
+	rows := func(start, end int) {
+		for r := start; r < end; r++ {
+			var colors []string
+			if r < len(doc.colors) {
+				colors = doc.colors[r]
+			}
+			line(doc.rows[r], colors)
+		}
+	}
+	if doc.sections == nil {
+		rows(0, len(doc.rows))
+	}
+	for _, section := range doc.sections {
+		title := section.title
+		if doc.colors != nil {
+			title = paint("bold", title)
+		}
+		b.WriteString("\n" + title + "\n")
+		rows(section.start, section.end)
+	}
+	for _, line := range doc.footer {
+		b.WriteString(line + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeJSON(w io.Writer, doc document) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc.value)
+}
+
+func writeJSONL(w io.Writer, doc document) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range doc.records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(comma rune) formatter {
+	return func(w io.Writer, doc document) error {
+		writer := csv.NewWriter(w)
+		writer.Comma = comma
+		if err := writer.Write(doc.columns); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(doc.rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	}
+}
+
+func writeMarkdown(w io.Writer, doc document) error {
+	cell := strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+	line := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for i, value := range cells {
+			escaped[i] = cell.Replace(value)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |"
+	}
+	separators := make([]string, len(doc.columns))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	lines := []string{line(doc.columns), line(separators)}
+	for _, row := range doc.rows {
+		lines = append(lines, line(row))
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// writeYAML converts the JSON form of the value, so field names and order
+// match --output json.
+func writeYAML(w io.Writer, doc document) error {
+	data, err := json.Marshal(doc.value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeOrdered(decoder)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	writeYAMLValue(&b, value, 0)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+type orderedField struct {
+	key   string
+	value any
+}
+
+type orderedObject []orderedField
+
+func decodeOrdered(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	switch delim {
+	case '{':
+		object := orderedObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, orderedField{key: key.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return object, err
+	default:
+		list := []any{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = decoder.Token()
+		return list, err
+	}
+}
+
+func writeYAMLValue(b *strings.Builder, value any, indent int) {
+	prefix := strings.Repeat(" ", indent)
+	switch v := value.(type) {
+	case orderedObject:
+		if len(v) == 0 {
+			b.WriteString(prefix + "{}\n")
+			return
+		}
+		for _, field := range v {
+			b.WriteString(prefix + yamlScalar(field.key) + ":")
+			if yamlInline(field.value) {
+				b.WriteString(" " + yamlInlineValue(field.value) + "\n")
+				continue
+			}
+			b.WriteString("\n")
+			writeYAMLValue(b, field.value, indent+2)
+		}
+	case []any:
+		if len(v) == 0 {
+			b.WriteString(prefix + "[]\n")
+			return
+		}
+		for _, item := range v {
+			if yamlInline(item) {
+				b.WriteString(prefix + "- " + yamlInlineValue(item) + "\n")
+				continue
+			}
+			var nested strings.Builder
+			writeYAMLValue(&nested, item, indent+2)
+			b.WriteString(prefix + "- " + nested.String()[indent+2:])
+		}
+	default:
+		b.WriteString(prefix + yamlInlineValue(v) + "\n")
+	}
+}
+
+func yamlInline(value any) bool {
+	switch v := value.(type) {
+	case orderedObject:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return true
+}
+
+func yamlInlineValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlScalar(v)
+	case orderedObject:
+		return "{}"
+	case []any:
+		return "[]"
+	}
+	return fmt.Sprint(value)
+}
+
+// yamlScalar quotes strings that YAML would read as something else (numbers,
+// booleans, dates) or that contain syntax characters.
+func yamlScalar(value string) string {
+	if value == "" || strings.TrimSpace(value) != value || strings.ContainsAny(value[:1], "-?:,[]{}#&*!|>'\"%@`0123456789.+~") ||
+		strings.Contains(value, ": ") || strings.Contains(value, " #") || strings.HasSuffix(value, ":") || strings.IndexFunc(value, func(r rune) bool { return r < ' ' }) >= 0 {
+		return quoteYAML(value)
+	}
+	switch strings.ToLower(value) {
+	case "true", "false", "null", "yes", "no", "on", "off", "y", "n":
+		return quoteYAML(value)
+	}
+	return value
+}
+
+func quoteYAML(value string) string {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	return strings.TrimSuffix(b.String(), "\n")
 }
 
 func nameOrEmpty(ref *api.NamedRef) string {
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const defaultPager = "less -FRX"
+
+// pagerCommand is the pager for output to a terminal: $EASY8_PAGER, then
+// $PAGER, then less. Setting either variable to "" or "cat" disables paging.
+func pagerCommand(noPager bool) string {
+	if noPager || !stdoutIsTerminal() {
+		return ""
+	}
+	for _, name := range []string{"EASY8_PAGER", "PAGER"} {
+		if value, ok := os.LookupEnv(name); ok {
+			if strings.TrimSpace(value) == "cat" {
+				return ""
+			}
+			return strings.TrimSpace(value)
+		}
+	}
+	return defaultPager
+}
+
+// writePaged writes data to w, through the pager when it has more lines
+// than the terminal. If the pager cannot be started the data is written
+// directly.
+func writePaged(w io.Writer, pager string, data []byte) error {
+	height := terminalHeight()
+	if pager == "" || height <= 0 || bytes.Count(data, []byte("\n")) < height {
+		_, err := w.Write(data)
+		return err
+	}
+	fields := strings.Fields(pager)
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		_, err := w.Write(data)
+		return err
+	}
+	// The pager's exit status only reflects how the user left it.
+	_ = cmd.Wait()
+	return nil
+}
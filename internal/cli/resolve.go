@@ -0,0 +1,460 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+	"github.com/Easy8Com/easy8-cli/internal/cache"
+	"github.com/Easy8Com/easy8-cli/pkg/easy8"
+)
+
+type lookupSource interface {
+	ListUsers(ctx context.Context) ([]api.User, error)
+	ListProjects(ctx context.Context) ([]api.Project, error)
+	ListIssueStatuses(ctx context.Context) ([]api.IssueStatus, error)
+	ListTrackers(ctx context.Context) ([]api.Tracker, error)
+	ListIssuePriorities(ctx context.Context) ([]api.IssuePriority, error)
+}
+
+// serviceLookups fetches the lookup tables through the services of client.
+type serviceLookups struct {
+	client *easy8.Client
+}
+
+func (l serviceLookups) ListUsers(ctx context.Context) ([]api.User, error) {
+	return l.client.Users.List(ctx)
+}
+
+func (l serviceLookups) ListProjects(ctx context.Context) ([]api.Project, error) {
+	return l.client.Projects.List(ctx)
+}
+
+func (l serviceLookups) ListIssueStatuses(ctx context.Context) ([]api.IssueStatus, error) {
+	return l.client.Statuses.List(ctx)
+}
+
+func (l serviceLookups) ListTrackers(ctx context.Context) ([]api.Tracker, error) {
+	return l.client.Trackers.List(ctx)
+}
+
+func (l serviceLookups) ListIssuePriorities(ctx context.Context) ([]api.IssuePriority, error) {
+	return l.client.Priorities.List(ctx)
+}
+
+type invalidator interface {
+	Invalidate(name string) bool
+}
+
+type userCache interface {
+	CachedUsers() ([]api.User, bool)
+}
+
+type searchFilters struct {
+	assigneeID optionalInt
+	statusID   optionalInt
+	priorityID optionalInt
+	taskTypeID optionalInt
+	projectID  optionalInt
+	assignee   string
+	status     string
+	priority   string
+	taskType   string
+	project    string
+}
+
+type resolvedFilters struct {
+	assigneeID int
+	statusID   int
+	priorityID int
+	taskTypeID int
+	projectID  int
+	notes      []string // close matches used for names
+}
+
+// resolveSearchFilters resolves all name filters concurrently. The assignee
+// waits for the project only when it needs the project's memberships.
+func resolveSearchFilters(ctx context.Context, client *easy8.Client, lookups lookupSource, filters searchFilters) (resolvedFilters, error) {
+	var resolved resolvedFilters
+	var assigneeErr, statusErr, priorityErr, taskTypeErr, projectErr error
+	projectDone := make(chan struct{})
+	matched := &matchNotes{}
+
+	var wg sync.WaitGroup
+	wg.Add(5)
+	go func() {
+		defer wg.Done()
+		defer close(projectDone)
+		resolved.projectID, projectErr = resolveProjectID(ctx, lookups, filters.projectID, filters.project, matched)
+	}()
+	go func() {
+		defer wg.Done()
+		resolved.statusID, statusErr = resolveStatusID(ctx, lookups, filters.statusID, filters.status, matched)
+	}()
+	go func() {
+		defer wg.Done()
+		resolved.priorityID, priorityErr = resolvePriorityID(ctx, lookups, filters.priorityID, filters.priority, matched)
+	}()
+	go func() {
+		defer wg.Done()
+		resolved.taskTypeID, taskTypeErr = resolveTaskTypeID(ctx, lookups, filters.taskTypeID, filters.taskType, matched)
+	}()
+	go func() {
+		defer wg.Done()
+		projectID := func() int {
+			<-projectDone
+			return resolved.projectID
+		}
+		resolved.assigneeID, assigneeErr = resolveAssigneeID(ctx, client, lookups, filters.assigneeID, filters.assignee, projectID, matched)
+	}()
+	wg.Wait()
+
+	for _, err := range []error{assigneeErr, statusErr, priorityErr, taskTypeErr, projectErr} {
+		if err != nil {
+			return resolvedFilters{}, err
+		}
+	}
+	resolved.notes = matched.lines
+	return resolved, nil
+}
+
+type nameID struct {
+	ID   int
+	Name string
+}
+
+type notFoundError struct {
+	label      string
+	name       string
+	candidates []candidate
+}
+
+func (err notFoundError) Error() string {
+	return fmt.Sprintf("%s not found: %s", err.label, err.name) + formatCandidates(err.candidates)
+}
+
+// mismatchError is a name flag that resolved to another ID than its ID flag.
+type mismatchError struct {
+	label string
+}
+
+func (err mismatchError) Error() string {
+	return fmt.Sprintf("%s-id does not match %s name", err.label, err.label)
+}
+
+// badName reports whether a lookup failed because of the name given (not
+// found, ambiguous or contradicting its ID flag) rather than the request.
+func badName(err error) bool {
+	var missing notFoundError
+	var ambiguous ambiguousError
+	var mismatch mismatchError
+	return errors.As(err, &missing) || errors.As(err, &ambiguous) || errors.As(err, &mismatch)
+}
+
+// retryOnMiss re-runs a lookup once against fresh data when the name was not
+// found in a table that came from the disk cache.
+func retryOnMiss[T any](lookups lookupSource, table string, resolve func() (T, error)) (T, error) {
+	value, err := resolve()
+	var missing notFoundError
+	if errors.As(err, &missing) {
+		if cached, ok := lookups.(invalidator); ok && cached.Invalidate(table) {
+			return resolve()
+		}
+	}
+	return value, err
+}
+
+func resolveAssigneeID(ctx context.Context, client *easy8.Client, lookups lookupSource, id optionalInt, name string, projectID func() int, matched *matchNotes) (int, error) {
+	if strings.TrimSpace(name) == "" {
+		if id.set {
+			return id.value, nil
+		}
+		return 0, nil
+	}
+
+	match, err := findUser(ctx, client, lookups, name, "assignee", projectID, matched)
+	if err != nil {
+		return 0, err
+	}
+	if id.set && id.value != match.ID {
+		return 0, mismatchError{label: "assignee"}
+	}
+	return match.ID, nil
+}
+
+func resolveSwitchUser(ctx context.Context, client *easy8.Client, lookups lookupSource, name string) (string, error) {
+	match, err := findUser(ctx, client, lookups, name, "as-user", nil, nil)
+	if err != nil {
+		var missing notFoundError
+		if errors.As(err, &missing) {
+			return "", err
+		}
+		return "", fmt.Errorf("resolving --as-user: %w", err)
+	}
+	if match.Login == "" {
+		return "", fmt.Errorf("as-user has no login: %s", name)
+	}
+	return match.Login, nil
+}
+
+// findUser tries, in order: a fresh cached users table (exact matches only),
+// the server-side name filter, the memberships of the selected project and
+// finally the full user listing. A step that finds no match falls through to
+// the next; only the last one needs permission to list all users. Close
+// matches are only accepted, and noted, with matched set.
+func findUser(ctx context.Context, client *easy8.Client, lookups lookupSource, name, label string, projectID func() int, matched *matchNotes) (api.User, error) {
+	needle := normalizeName(name)
+
+	if cached, ok := lookups.(userCache); ok {
+		if all, ok := cached.CachedUsers(); ok {
+			var matches []api.User
+			for _, user := range all {
+				if matchesUser(user, needle) {
+					matches = append(matches, user)
+				}
+			}
+			if len(matches) > 0 {
+				return pickUser(matches, name, label, matched)
+			}
+		}
+	}
+
+	// missed reports a not-found result, keeping the last one that has
+	// suggestions for when the full listing is not allowed.
+	var suggested []candidate
+	missed := func(err error) bool {
+		var missing notFoundError
+		if !errors.As(err, &missing) {
+			return false
+		}
+		if len(missing.candidates) > 0 {
+			suggested = missing.candidates
+		}
+		return true
+	}
+
+	searched := false
+	if client != nil {
+		found, err := client.Users.Search(ctx, name)
+		switch {
+		case err == nil:
+			searched = true
+			if match, err := pickUser(found, name, label, matched); !missed(err) {
+				return match, err
+			}
+		case !permissionDenied(err):
+			return api.User{}, err
+		}
+
+		if projectID != nil {
+			if id := projectID(); id > 0 {
+				memberships, err := client.Projects.Memberships(ctx, id)
+				if err != nil && !permissionDenied(err) {
+					return api.User{}, err
+				}
+				if match, err := pickMember(memberships, name, label, matched); !missed(err) {
+					return match, err
+				}
+			}
+		}
+	}
+
+	return retryOnMiss(lookups, cache.Users, func() (api.User, error) {
+		all, err := lookups.ListUsers(ctx)
+		if err != nil {
+			if searched && permissionDenied(err) {
+				return api.User{}, notFoundError{label: label, name: name, candidates: suggested}
+			}
+			return api.User{}, err
+		}
+		return pickUser(all, name, label, matched)
+	})
+}
+
+func pickUser(users []api.User, name, label string, matched *matchNotes) (api.User, error) {
+	items := make([]candidate, 0, len(users))
+	for _, user := range users {
+		full := strings.TrimSpace(user.Firstname + " " + user.Lastname)
+		display := full
+		switch {
+		case display == "":
+			display = user.Login
+		case user.Login != "":
+			display = fmt.Sprintf("%s (%s)", full, user.Login)
+		}
+		items = append(items, candidate{ID: user.ID, Label: display, Keys: []string{user.Login, full}})
+	}
+	match, err := pickName(items, name, label, matched)
+	if err != nil {
+		return api.User{}, err
+	}
+	for _, user := range users {
+		if user.ID == match.ID {
+			return user, nil
+		}
+	}
+	return api.User{}, notFoundError{label: label, name: name}
+}
+
+// pickMember resolves name against the users of project memberships. A
+// membership only carries the user's display name, so there is no login to
+// match; the caller falls back to the full listing for that.
+func pickMember(memberships []api.Membership, name, label string, matched *matchNotes) (api.User, error) {
+	var items []candidate
+	for _, membership := range memberships {
+		if membership.User != nil {
+			items = append(items, candidate{ID: membership.User.ID, Label: membership.User.Name, Keys: []string{membership.User.Name}})
+		}
+	}
+	match, err := pickName(items, name, label, matched)
+	if err != nil {
+		return api.User{}, err
+	}
+	return api.User{ID: match.ID}, nil
+}
+
+// pickName is pickCandidate, or pickExactCandidate without matched.
+func pickName(items []candidate, name, label string, matched *matchNotes) (candidate, error) {
+	if matched == nil {
+		return pickExactCandidate(items, name, label)
+	}
+	return pickCandidate(items, name, label, matched)
+}
+
+func permissionDenied(err error) bool {
+	var apiErr api.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	kind := apiErr.Kind()
+	return kind == api.ErrorKindForbidden || kind == api.ErrorKindNotFound
+}
+
+func resolveStatusID(ctx context.Context, lookups lookupSource, id optionalInt, name string, matched *matchNotes) (int, error) {
+	if strings.TrimSpace(name) == "" {
+		if id.set {
+			return id.value, nil
+		}
+		return 0, nil
+	}
+	return retryOnMiss(lookups, cache.Statuses, func() (int, error) {
+		items, err := lookups.ListIssueStatuses(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return resolveNameID(id, name, toNameIDsStatus(items), "status", matched)
+	})
+}
+
+func resolvePriorityID(ctx context.Context, lookups lookupSource, id optionalInt, name string, matched *matchNotes) (int, error) {
+	if strings.TrimSpace(name) == "" {
+		if id.set {
+			return id.value, nil
+		}
+		return 0, nil
+	}
+	return retryOnMiss(lookups, cache.Priorities, func() (int, error) {
+		items, err := lookups.ListIssuePriorities(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return resolveNameID(id, name, toNameIDsPriority(items), "priority", matched)
+	})
+}
+
+func resolveTaskTypeID(ctx context.Context, lookups lookupSource, id optionalInt, name string, matched *matchNotes) (int, error) {
+	if strings.TrimSpace(name) == "" {
+		if id.set {
+			return id.value, nil
+		}
+		return 0, nil
+	}
+	return retryOnMiss(lookups, cache.Trackers, func() (int, error) {
+		items, err := lookups.ListTrackers(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return resolveNameID(id, name, toNameIDsTracker(items), "task-type", matched)
+	})
+}
+
+func resolveProjectID(ctx context.Context, lookups lookupSource, id optionalInt, name string, matched *matchNotes) (int, error) {
+	if strings.TrimSpace(name) == "" {
+		if id.set {
+			return id.value, nil
+		}
+		return 0, nil
+	}
+	return retryOnMiss(lookups, cache.Projects, func() (int, error) {
+		items, err := lookups.ListProjects(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return resolveNameID(id, name, toNameIDsProject(items), "project", matched)
+	})
+}
+
+func resolveNameID(id optionalInt, name string, items []nameID, label string, matched *matchNotes) (int, error) {
+	candidates := make([]candidate, 0, len(items))
+	for _, item := range items {
+		candidates = append(candidates, candidate{ID: item.ID, Label: item.Name, Keys: []string{item.Name}})
+	}
+	match, err := pickCandidate(candidates, name, label, matched)
+	if err != nil {
+		return 0, err
+	}
+	if id.set && id.value != match.ID {
+		return 0, mismatchError{label: label}
+	}
+	return match.ID, nil
+}
+
+func normalizeName(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+func matchesUser(user api.User, needle string) bool {
+	if normalizeName(user.Login) == needle {
+		return true
+	}
+	full := strings.TrimSpace(user.Firstname + " " + user.Lastname)
+	if normalizeName(full) == needle {
+		return true
+	}
+	return false
+}
+
+func toNameIDsStatus(items []api.IssueStatus) []nameID {
+	result := make([]nameID, 0, len(items))
+	for _, item := range items {
+		result = append(result, nameID{ID: item.ID, Name: item.Name})
+	}
+	return result
+}
+
+func toNameIDsPriority(items []api.IssuePriority) []nameID {
+	result := make([]nameID, 0, len(items))
+	for _, item := range items {
+		result = append(result, nameID{ID: item.ID, Name: item.Name})
+	}
+	return result
+}
+
+func toNameIDsTracker(items []api.Tracker) []nameID {
+	result := make([]nameID, 0, len(items))
+	for _, item := range items {
+		result = append(result, nameID{ID: item.ID, Name: item.Name})
+	}
+	return result
+}
+
+func toNameIDsProject(items []api.Project) []nameID {
+	result := make([]nameID, 0, len(items))
+	for _, item := range items {
+		result = append(result, nameID{ID: item.ID, Name: item.Name})
+	}
+	return result
+}
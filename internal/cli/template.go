@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+var timeNow = time.Now
+
+var stdoutIsTerminal = func() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+var ansiColors = map[string]string{
+	"bold":    "1",
+	"dim":     "2",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"gray":    "90",
+}
+
+// parseTemplate compiles the --template value: inline text when it contains
+// an action, otherwise the name of a template from the config.
+func parseTemplate(value, file string, named map[string]string, colored bool) (*template.Template, error) {
+	text := value
+	switch {
+	case value != "" && file != "":
+		return nil, fmt.Errorf("--template and --template-file cannot be combined")
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		text = strings.TrimSuffix(string(data), "\n")
+	case value == "":
+		return nil, nil
+	case !strings.Contains(value, "{{"):
+		var ok bool
+		if text, ok = named[value]; !ok {
+			names := make([]string, 0, len(named))
+			for name := range named {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if len(names) == 0 {
+				return nil, fmt.Errorf("unknown template %q (no templates in config)", value)
+			}
+			return nil, fmt.Errorf("unknown template %q (config has: %s)", value, strings.Join(names, ", "))
+		}
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs(colored)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func templateFuncs(colored bool) template.FuncMap {
+	return template.FuncMap{
+		"truncate": truncate,
+		"pad":      pad,
+		"color": func(name string, value any) (string, error) {
+			return color(name, value, colored)
+		},
+		"timeago": timeAgo,
+		"join":    join,
+		"nameOf":  nameOrEmpty,
+	}
+}
+
+// truncate shortens s to n characters, ending with an ellipsis when cut.
+func truncate(n int, value any) string {
+	s := fmt.Sprint(value)
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+// pad fills s with spaces to n characters; a negative n right-aligns.
+func pad(n int, value any) string {
+	s := fmt.Sprint(value)
+	width := n
+	if width < 0 {
+		width = -width
+	}
+	fill := width - utf8.RuneCountInString(s)
+	if fill <= 0 {
+		return s
+	}
+	if n < 0 {
+		return strings.Repeat(" ", fill) + s
+	}
+	return s + strings.Repeat(" ", fill)
+}
+
+func color(name string, value any, enabled bool) (string, error) {
+	if _, ok := ansiColors[name]; !ok {
+		return "", fmt.Errorf("unknown color %q", name)
+	}
+	s := fmt.Sprint(value)
+	if !enabled {
+		return s, nil
+	}
+	return paint(name, s), nil
+}
+
+// timeAgo renders an API timestamp or date relative to now, e.g. "3 days ago".
+func timeAgo(value any) (string, error) {
+	return relativeTime(value, false)
+}
+
+// shortTimeAgo is the compact form used by the table format, e.g. "3h ago".
+// Values that are not timestamps are returned unchanged.
+func shortTimeAgo(value string) string {
+	s, err := relativeTime(value, true)
+	if err != nil {
+		return value
+	}
+	return s
+}
+
+func relativeTime(value any, short bool) (string, error) {
+	var at time.Time
+	switch v := value.(type) {
+	case time.Time:
+		at = v
+	case string:
+		if v == "" {
+			return "", nil
+		}
+		var err error
+		if at, err = time.Parse(time.RFC3339, v); err != nil {
+			if at, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+				return "", fmt.Errorf("timeago: %q is not a timestamp", v)
+			}
+		}
+	default:
+		return "", fmt.Errorf("timeago: unsupported value %v", value)
+	}
+
+	elapsed := timeNow().Sub(at)
+	suffix := " ago"
+	prefix := ""
+	if elapsed < 0 {
+		elapsed = -elapsed
+		suffix, prefix = "", "in "
+	}
+	units := []struct {
+		size        time.Duration
+		name, short string
+	}{
+		{365 * 24 * time.Hour, "year", "y"},
+		{30 * 24 * time.Hour, "month", "mo"},
+		{7 * 24 * time.Hour, "week", "w"},
+		{24 * time.Hour, "day", "d"},
+		{time.Hour, "hour", "h"},
+		{time.Minute, "minute", "m"},
+	}
+	for _, unit := range units {
+		if count := int(elapsed / unit.size); count >= 1 {
+			if short {
+				return fmt.Sprintf("%s%d%s%s", prefix, count, unit.short, suffix), nil
+			}
+			plural := ""
+			if count > 1 {
+				plural = "s"
+			}
+			return fmt.Sprintf("%s%d %s%s%s", prefix, count, unit.name, plural, suffix), nil
+		}
+	}
+	return "just now", nil
+}
+
+// join concatenates the elements of a slice; named references contribute
+// their names.
+func join(sep string, list any) (string, error) {
+	if list == nil {
+		return "", nil
+	}
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: %T is not a list", list)
+	}
+	parts := make([]string, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		switch item := value.Index(i).Interface().(type) {
+		case api.NamedRef:
+			parts = append(parts, item.Name)
+		case *api.NamedRef:
+			parts = append(parts, nameOrEmpty(item))
+		default:
+			parts = append(parts, fmt.Sprint(item))
+		}
+	}
+	return strings.Join(parts, sep), nil
+}
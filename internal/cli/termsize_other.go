@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package cli
+
+// ttySize is unknown here; set COLUMNS and LINES to describe the terminal.
+func ttySize(fd uintptr) (width, height int) {
+	return 0, 0
+}
@@ -3,9 +3,16 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+)
+
+const (
+	AuthModeAPIKey = "api_key"
+	AuthModeOAuth2 = "oauth2"
 )
 
 type Defaults struct {
@@ -17,27 +24,109 @@ type Defaults struct {
 	AssignedToID int `json:"assigned_to_id"`
 }
 
+type OAuth struct {
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	AuthorizeURL string   `json:"authorize_url,omitempty"`
+	TokenURL     string   `json:"token_url,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+	RedirectPort int      `json:"redirect_port,omitempty"`
+}
+
+type Retry struct {
+	Attempts    int      `json:"attempts,omitempty"`
+	BaseDelayMS int      `json:"base_delay_ms,omitempty"`
+	MaxDelayMS  int      `json:"max_delay_ms,omitempty"`
+	Jitter      *float64 `json:"jitter,omitempty"`
+}
+
 type Config struct {
-	BaseURL  string   `json:"base_url"`
-	APIKey   string   `json:"api_key"`
-	Defaults Defaults `json:"defaults"`
+	BaseURL     string  `json:"base_url"`
+	APIKey      string  `json:"api_key"`
+	AuthMode    string  `json:"auth_mode,omitempty"`
+	OAuth       OAuth   `json:"oauth"`
+	AsUser      string  `json:"as_user,omitempty"`
+	Retry       Retry   `json:"retry"`
+	RPS         float64 `json:"rps,omitempty"`
+	Concurrency int     `json:"concurrency,omitempty"`
+	CacheTTL    int     `json:"cache_ttl,omitempty"`
+
+	CAFile             string `json:"ca_file,omitempty"`
+	ClientCert         string `json:"client_cert,omitempty"`
+	ClientKey          string `json:"client_key,omitempty"`
+	InsecureSkipVerify *bool  `json:"insecure_skip_verify,omitempty"`
+	ProxyURL           string `json:"proxy_url,omitempty"`
+	RequestTimeout     int    `json:"request_timeout,omitempty"`
+
+	Defaults Defaults          `json:"defaults"`
+	Profile  string            `json:"profile,omitempty"`
+	Profiles map[string]Config `json:"profiles,omitempty"`
+
+	// Templates are named --template formats.
+	Templates map[string]string `json:"templates,omitempty"`
+	// Columns are the default issue table columns (see --columns).
+	Columns []string `json:"columns,omitempty"`
+	// Color is "auto" (the default: only on a terminal), "always" or "never".
+	Color string `json:"color,omitempty"`
 }
 
 func Load() (Config, error) {
+	return LoadProfile("")
+}
+
+// LoadProfile loads the config and overlays the named profile. An empty name
+// falls back to EASY8_PROFILE and then to the "profile" key of the config file.
+func LoadProfile(name string) (Config, error) {
 	cfg := Config{
 		BaseURL: "https://demo.easysoftware.com",
 	}
 
-	if fileCfg, err := readFileConfig(); err == nil {
-		cfg = mergeConfig(cfg, fileCfg)
-	} else if !errors.Is(err, os.ErrNotExist) {
+	fileCfg, err := readFileConfig()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return Config{}, err
 	}
+	cfg = mergeConfig(cfg, fileCfg)
+
+	if name == "" {
+		name = os.Getenv("EASY8_PROFILE")
+	}
+	if name == "" {
+		name = fileCfg.Profile
+	}
+	cfg.Profile = ""
+	cfg.Profiles = nil
+	if name != "" {
+		profile, ok := fileCfg.Profiles[name]
+		if !ok {
+			return Config{}, fmt.Errorf("unknown profile: %s", name)
+		}
+		cfg = mergeConfig(cfg, profile)
+		cfg.Profile = name
+	}
 
 	applyEnv(&cfg)
+	if cfg.AuthMode != "" && cfg.AuthMode != AuthModeAPIKey && cfg.AuthMode != AuthModeOAuth2 {
+		return Config{}, fmt.Errorf("invalid auth_mode: %s", cfg.AuthMode)
+	}
 	return cfg, nil
 }
 
+// Insecure reports whether TLS certificate verification is switched off.
+func (c Config) Insecure() bool {
+	return c.InsecureSkipVerify != nil && *c.InsecureSkipVerify
+}
+
+func TokenPath(profile string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		profile = "default"
+	}
+	return filepath.Join(dir, "tokens", profile+".json"), nil
+}
+
 func readFileConfig() (Config, error) {
 	path, err := configPath()
 	if err != nil {
@@ -58,11 +147,19 @@ func readFileConfig() (Config, error) {
 }
 
 func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+func configDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(home, ".config", "easy8", "config.json"), nil
+	return filepath.Join(home, ".config", "easy8"), nil
 }
 
 func applyEnv(cfg *Config) {
@@ -72,7 +169,37 @@ func applyEnv(cfg *Config) {
 	if key := os.Getenv("EASY8_API_KEY"); key != "" {
 		cfg.APIKey = key
 	}
+	if mode := os.Getenv("EASY8_AUTH_MODE"); mode != "" {
+		cfg.AuthMode = strings.ToLower(mode)
+	}
+	if clientID := os.Getenv("EASY8_OAUTH_CLIENT_ID"); clientID != "" {
+		cfg.OAuth.ClientID = clientID
+	}
+	if secret := os.Getenv("EASY8_OAUTH_CLIENT_SECRET"); secret != "" {
+		cfg.OAuth.ClientSecret = secret
+	}
+	if asUser := os.Getenv("EASY8_AS_USER"); asUser != "" {
+		cfg.AsUser = asUser
+	}
 
+	if caFile := os.Getenv("EASY8_CA_FILE"); caFile != "" {
+		cfg.CAFile = caFile
+	}
+	if color := os.Getenv("EASY8_COLOR"); color != "" {
+		cfg.Color = color
+	}
+	if proxy := os.Getenv("EASY8_PROXY_URL"); proxy != "" {
+		cfg.ProxyURL = proxy
+	}
+	setIntEnv(&cfg.RequestTimeout, "EASY8_REQUEST_TIMEOUT")
+	setIntEnv(&cfg.CacheTTL, "EASY8_CACHE_TTL")
+	setIntEnv(&cfg.Retry.Attempts, "EASY8_RETRY_ATTEMPTS")
+	setIntEnv(&cfg.Concurrency, "EASY8_CONCURRENCY")
+	if value := os.Getenv("EASY8_RPS"); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			cfg.RPS = parsed
+		}
+	}
 	setIntEnv(&cfg.Defaults.ProjectID, "EASY8_DEFAULT_PROJECT_ID")
 	setIntEnv(&cfg.Defaults.TrackerID, "EASY8_DEFAULT_TRACKER_ID")
 	setIntEnv(&cfg.Defaults.StatusID, "EASY8_DEFAULT_STATUS_ID")
@@ -100,6 +227,69 @@ func mergeConfig(base Config, overlay Config) Config {
 	if overlay.APIKey != "" {
 		base.APIKey = overlay.APIKey
 	}
+	if overlay.AuthMode != "" {
+		base.AuthMode = overlay.AuthMode
+	}
+	if overlay.AsUser != "" {
+		base.AsUser = overlay.AsUser
+	}
+	if overlay.CAFile != "" {
+		base.CAFile = overlay.CAFile
+	}
+	if overlay.ClientCert != "" {
+		base.ClientCert = overlay.ClientCert
+	}
+	if overlay.ClientKey != "" {
+		base.ClientKey = overlay.ClientKey
+	}
+	if overlay.InsecureSkipVerify != nil {
+		base.InsecureSkipVerify = overlay.InsecureSkipVerify
+	}
+	if overlay.ProxyURL != "" {
+		base.ProxyURL = overlay.ProxyURL
+	}
+	if overlay.RequestTimeout != 0 {
+		base.RequestTimeout = overlay.RequestTimeout
+	}
+	if overlay.CacheTTL != 0 {
+		base.CacheTTL = overlay.CacheTTL
+	}
+	if overlay.RPS != 0 {
+		base.RPS = overlay.RPS
+	}
+	if overlay.Concurrency != 0 {
+		base.Concurrency = overlay.Concurrency
+	}
+	if overlay.Retry.Attempts != 0 {
+		base.Retry.Attempts = overlay.Retry.Attempts
+	}
+	if overlay.Retry.BaseDelayMS != 0 {
+		base.Retry.BaseDelayMS = overlay.Retry.BaseDelayMS
+	}
+	if overlay.Retry.MaxDelayMS != 0 {
+		base.Retry.MaxDelayMS = overlay.Retry.MaxDelayMS
+	}
+	if overlay.Retry.Jitter != nil {
+		base.Retry.Jitter = overlay.Retry.Jitter
+	}
+	if overlay.OAuth.ClientID != "" {
+		base.OAuth.ClientID = overlay.OAuth.ClientID
+	}
+	if overlay.OAuth.ClientSecret != "" {
+		base.OAuth.ClientSecret = overlay.OAuth.ClientSecret
+	}
+	if overlay.OAuth.AuthorizeURL != "" {
+		base.OAuth.AuthorizeURL = overlay.OAuth.AuthorizeURL
+	}
+	if overlay.OAuth.TokenURL != "" {
+		base.OAuth.TokenURL = overlay.OAuth.TokenURL
+	}
+	if len(overlay.OAuth.Scopes) > 0 {
+		base.OAuth.Scopes = overlay.OAuth.Scopes
+	}
+	if overlay.OAuth.RedirectPort != 0 {
+		base.OAuth.RedirectPort = overlay.OAuth.RedirectPort
+	}
 
 	if overlay.Defaults.ProjectID != 0 {
 		base.Defaults.ProjectID = overlay.Defaults.ProjectID
@@ -119,6 +309,22 @@ func mergeConfig(base Config, overlay Config) Config {
 	if overlay.Defaults.AssignedToID != 0 {
 		base.Defaults.AssignedToID = overlay.Defaults.AssignedToID
 	}
+	if len(overlay.Columns) > 0 {
+		base.Columns = overlay.Columns
+	}
+	if overlay.Color != "" {
+		base.Color = overlay.Color
+	}
+	if len(overlay.Templates) > 0 {
+		templates := map[string]string{}
+		for name, text := range base.Templates {
+			templates[name] = text
+		}
+		for name, text := range overlay.Templates {
+			templates[name] = text
+		}
+		base.Templates = templates
+	}
 
 	return base
 }
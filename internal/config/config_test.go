@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -33,6 +34,7 @@ func TestLoadEnvOverrides(t *testing.T) {
 	t.Setenv("EASY8_DEFAULT_PRIORITY_ID", "13")
 	t.Setenv("EASY8_DEFAULT_AUTHOR_ID", "14")
 	t.Setenv("EASY8_DEFAULT_ASSIGNED_TO_ID", "15")
+	t.Setenv("EASY8_AS_USER", "bot")
 
 	cfg, err := Load()
 	if err != nil {
@@ -62,6 +64,9 @@ func TestLoadEnvOverrides(t *testing.T) {
 	if cfg.Defaults.AssignedToID != 15 {
 		t.Fatalf("AssignedToID = %d", cfg.Defaults.AssignedToID)
 	}
+	if cfg.AsUser != "bot" {
+		t.Fatalf("AsUser = %q", cfg.AsUser)
+	}
 }
 
 func TestLoadConfigFileMerge(t *testing.T) {
@@ -111,3 +116,98 @@ func TestLoadConfigFileMerge(t *testing.T) {
 		t.Fatalf("ProjectID = %d", cfg.Defaults.ProjectID)
 	}
 }
+
+func TestLoadProfileOverlay(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("EASY8_BASE_URL", "")
+	t.Setenv("EASY8_API_KEY", "")
+	t.Setenv("EASY8_PROFILE", "")
+
+	path := filepath.Join(home, ".config", "easy8")
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	skipVerify, verify := true, false
+	fileCfg := Config{
+		BaseURL:            "https://main",
+		APIKey:             "main-key",
+		Profile:            "customer",
+		InsecureSkipVerify: &skipVerify,
+		Templates: map[string]string{
+			"short": "{{.ID}}",
+			"wide":  "{{.ID}} {{.Subject}}",
+		},
+		Profiles: map[string]Config{
+			"customer": {
+				BaseURL:   "https://customer",
+				AuthMode:  AuthModeOAuth2,
+				OAuth:     OAuth{ClientID: "cli", Scopes: []string{"read"}},
+				Templates: map[string]string{"short": "#{{.ID}}"},
+				Columns:   []string{"id", "subject"},
+				Color:     "always",
+
+				InsecureSkipVerify: &verify,
+			},
+			"other": {BaseURL: "https://other"},
+		},
+	}
+	data, err := json.Marshal(fileCfg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "config.json"), data, 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if cfg.Profile != "customer" || cfg.BaseURL != "https://customer" || cfg.APIKey != "main-key" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.AuthMode != AuthModeOAuth2 || cfg.OAuth.ClientID != "cli" {
+		t.Fatalf("unexpected auth: %+v", cfg)
+	}
+	if cfg.Templates["short"] != "#{{.ID}}" || cfg.Templates["wide"] != "{{.ID}} {{.Subject}}" {
+		t.Fatalf("unexpected templates: %v", cfg.Templates)
+	}
+	if strings.Join(cfg.Columns, ",") != "id,subject" {
+		t.Fatalf("unexpected columns: %v", cfg.Columns)
+	}
+	if cfg.Color != "always" {
+		t.Fatalf("unexpected color: %q", cfg.Color)
+	}
+	if cfg.Insecure() {
+		t.Fatalf("profile should switch insecure_skip_verify off")
+	}
+
+	cfg, err = LoadProfile("other")
+	if err != nil {
+		t.Fatalf("LoadProfile error: %v", err)
+	}
+	if cfg.Profile != "other" || cfg.BaseURL != "https://other" || cfg.AuthMode != "" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if !cfg.Insecure() {
+		t.Fatalf("insecure_skip_verify should be inherited from the base config")
+	}
+
+	if _, err := LoadProfile("missing"); err == nil {
+		t.Fatalf("expected error for unknown profile")
+	}
+}
+
+func TestTokenPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path, err := TokenPath("")
+	if err != nil {
+		t.Fatalf("TokenPath error: %v", err)
+	}
+	if path != filepath.Join(home, ".config", "easy8", "tokens", "default.json") {
+		t.Fatalf("path = %q", path)
+	}
+}
@@ -0,0 +1,337 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type function struct {
+	min, max int // max < 0 means variadic
+	fn       func(args []any) (any, error)
+}
+
+func (f function) arity() string {
+	switch {
+	case f.max < 0:
+		return fmt.Sprintf("at least %d arguments", f.min)
+	case f.min == f.max && f.min == 1:
+		return "1 argument"
+	case f.min == f.max:
+		return fmt.Sprintf("%d arguments", f.min)
+	}
+	return fmt.Sprintf("%d to %d arguments", f.min, f.max)
+}
+
+var functions = map[string]function{
+	"length":      {1, 1, fnLength},
+	"keys":        {1, 1, fnKeys},
+	"values":      {1, 1, fnValues},
+	"contains":    {2, 2, fnContains},
+	"starts_with": {2, 2, fnStartsWith},
+	"ends_with":   {2, 2, fnEndsWith},
+	"join":        {2, 2, fnJoin},
+	"sort":        {1, 1, fnSort},
+	"sort_by":     {2, 2, fnSortBy},
+	"reverse":     {1, 1, fnReverse},
+	"max":         {1, 1, fnMax},
+	"min":         {1, 1, fnMin},
+	"sum":         {1, 1, fnSum},
+	"avg":         {1, 1, fnAvg},
+	"to_string":   {1, 1, fnToString},
+	"to_number":   {1, 1, fnToNumber},
+	"type":        {1, 1, fnType},
+	"not_null":    {1, -1, fnNotNull},
+}
+
+func typeError(want string, got any) error {
+	return fmt.Errorf("expected %s, got %s", want, typeName(got))
+}
+
+func fnLength(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case []any:
+		return float64(len(v)), nil
+	case map[string]any:
+		return float64(len(v)), nil
+	}
+	return nil, typeError("string, array or object", args[0])
+}
+
+func fnKeys(args []any) (any, error) {
+	object, ok := args[0].(map[string]any)
+	if !ok {
+		return nil, typeError("object", args[0])
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]any, len(keys))
+	for i, key := range keys {
+		result[i] = key
+	}
+	return result, nil
+}
+
+func fnValues(args []any) (any, error) {
+	object, ok := args[0].(map[string]any)
+	if !ok {
+		return nil, typeError("object", args[0])
+	}
+	return objectValues(object), nil
+}
+
+func fnContains(args []any) (any, error) {
+	switch subject := args[0].(type) {
+	case string:
+		search, ok := args[1].(string)
+		return ok && strings.Contains(subject, search), nil
+	case []any:
+		for _, item := range subject {
+			if reflect.DeepEqual(item, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return nil, typeError("string or array", args[0])
+}
+
+func fnStartsWith(args []any) (any, error) {
+	subject, prefix, err := twoStrings(args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(subject, prefix), nil
+}
+
+func fnEndsWith(args []any) (any, error) {
+	subject, suffix, err := twoStrings(args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(subject, suffix), nil
+}
+
+func twoStrings(args []any) (string, string, error) {
+	a, ok := args[0].(string)
+	if !ok {
+		return "", "", typeError("string", args[0])
+	}
+	b, ok := args[1].(string)
+	if !ok {
+		return "", "", typeError("string", args[1])
+	}
+	return a, b, nil
+}
+
+func fnJoin(args []any) (any, error) {
+	sep, ok := args[0].(string)
+	if !ok {
+		return nil, typeError("string separator", args[0])
+	}
+	list, ok := args[1].([]any)
+	if !ok {
+		return nil, typeError("array", args[1])
+	}
+	parts := make([]string, len(list))
+	for i, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, typeError("array of strings", item)
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// sortable checks that keys are all numbers or all strings.
+func sortable(keys []any) error {
+	for _, key := range keys {
+		if _, ok := compare(key, keys[0]); !ok {
+			return typeError("numbers or strings of one type", key)
+		}
+	}
+	return nil
+}
+
+func fnSort(args []any) (any, error) {
+	list, ok := args[0].([]any)
+	if !ok {
+		return nil, typeError("array", args[0])
+	}
+	if err := sortable(list); err != nil {
+		return nil, err
+	}
+	result := append([]any(nil), list...)
+	sort.SliceStable(result, func(i, j int) bool {
+		cmp, _ := compare(result[i], result[j])
+		return cmp < 0
+	})
+	return result, nil
+}
+
+func fnSortBy(args []any) (any, error) {
+	list, ok := args[0].([]any)
+	if !ok {
+		return nil, typeError("array", args[0])
+	}
+	ref, ok := args[1].(expressionRef)
+	if !ok {
+		return nil, typeError("expression (&field)", args[1])
+	}
+	keys := make([]any, len(list))
+	for i, item := range list {
+		key, err := ref.inner.eval(item)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	if err := sortable(keys); err != nil {
+		return nil, err
+	}
+	order := make([]int, len(list))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		cmp, _ := compare(keys[order[i]], keys[order[j]])
+		return cmp < 0
+	})
+	result := make([]any, len(list))
+	for i, from := range order {
+		result[i] = list[from]
+	}
+	return result, nil
+}
+
+func fnReverse(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case string:
+		runes := []rune(v)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[len(v)-1-i] = item
+		}
+		return result, nil
+	}
+	return nil, typeError("string or array", args[0])
+}
+
+func extreme(args []any, better int) (any, error) {
+	list, ok := args[0].([]any)
+	if !ok {
+		return nil, typeError("array", args[0])
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	if err := sortable(list); err != nil {
+		return nil, err
+	}
+	best := list[0]
+	for _, item := range list[1:] {
+		if cmp, _ := compare(item, best); cmp == better {
+			best = item
+		}
+	}
+	return best, nil
+}
+
+func fnMax(args []any) (any, error) {
+	return extreme(args, 1)
+}
+
+func fnMin(args []any) (any, error) {
+	return extreme(args, -1)
+}
+
+func numbers(value any) ([]float64, error) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, typeError("array", value)
+	}
+	result := make([]float64, len(list))
+	for i, item := range list {
+		n, ok := item.(float64)
+		if !ok {
+			return nil, typeError("array of numbers", item)
+		}
+		result[i] = n
+	}
+	return result, nil
+}
+
+func fnSum(args []any) (any, error) {
+	list, err := numbers(args[0])
+	if err != nil {
+		return nil, err
+	}
+	total := 0.0
+	for _, n := range list {
+		total += n
+	}
+	return total, nil
+}
+
+func fnAvg(args []any) (any, error) {
+	list, err := numbers(args[0])
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	total, _ := fnSum(args)
+	return total.(float64) / float64(len(list)), nil
+}
+
+func fnToString(args []any) (any, error) {
+	if s, ok := args[0].(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func fnToNumber(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case float64:
+		return v, nil
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+			return nil, nil
+		}
+		return n, nil
+	}
+	return nil, nil
+}
+
+func fnType(args []any) (any, error) {
+	return typeName(args[0]), nil
+}
+
+func fnNotNull(args []any) (any, error) {
+	for _, arg := range args {
+		if arg != nil {
+			return arg, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,968 @@
+// Package query evaluates a subset of JMESPath (https://jmespath.org) over
+// decoded JSON values: fields, indexes, slices, projections, filters,
+// multiselect lists and hashes, pipes, comparisons and the common functions.
+// Bare numbers and string ordering comparisons are accepted as extensions.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Query is a compiled expression.
+type Query struct {
+	source string
+	root   node
+}
+
+// Compile parses a JMESPath expression.
+func Compile(expression string) (*Query, error) {
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.unexpected(tok)
+	}
+	return &Query{source: expression, root: root}, nil
+}
+
+// Search applies the query to data, which must use the types produced by
+// encoding/json decoding into an interface value.
+func (q *Query) Search(data any) (any, error) {
+	return q.root.eval(data)
+}
+
+func (q *Query) String() string {
+	return q.source
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuoted
+	tokRaw
+	tokLiteral
+	tokNumber
+	tokPunct
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value any
+	pos   int
+}
+
+// punctuation lists longer operators first so they win over their prefixes.
+var punctuation = []string{"||", "&&", "==", "!=", "<=", ">=", ".", "*", "[", "]", "{", "}", "(", ")", ",", ":", "|", "&", "!", "<", ">", "@", "?"}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(input) && (input[i] == '_' || input[i] >= 'a' && input[i] <= 'z' || input[i] >= 'A' && input[i] <= 'Z' || input[i] >= '0' && input[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: input[start:i], pos: start})
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(input) && input[i+1] >= '0' && input[i+1] <= '9':
+			start := i
+			i++
+			for i < len(input) && (input[i] >= '0' && input[i] <= '9' || input[i] == '.' && i+1 < len(input) && input[i+1] >= '0' && input[i+1] <= '9') {
+				i++
+			}
+			number, err := strconv.ParseFloat(input[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", input[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: input[start:i], value: number, pos: start})
+		case c == '"' || c == '\'' || c == '`':
+			end := closing(input, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %c at %d", c, i)
+			}
+			body := input[i+1 : end]
+			tok := token{text: input[i : end+1], pos: i}
+			switch c {
+			case '"':
+				var name string
+				if err := json.Unmarshal([]byte(input[i:end+1]), &name); err != nil {
+					return nil, fmt.Errorf("invalid quoted identifier at %d", i)
+				}
+				tok.kind, tok.value = tokQuoted, name
+			case '\'':
+				tok.kind, tok.value = tokRaw, strings.ReplaceAll(body, `\'`, `'`)
+			default:
+				var value any
+				if err := json.Unmarshal([]byte(strings.ReplaceAll(body, "\\`", "`")), &value); err != nil {
+					return nil, fmt.Errorf("invalid literal %s at %d", tok.text, i)
+				}
+				tok.kind, tok.value = tokLiteral, value
+			}
+			tokens = append(tokens, tok)
+			i = end + 1
+		default:
+			matched := false
+			for _, punct := range punctuation {
+				if strings.HasPrefix(input[i:], punct) {
+					tokens = append(tokens, token{kind: tokPunct, text: punct, pos: i})
+					i += len(punct)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				r, _ := utf8.DecodeRuneInString(input[i:])
+				return nil, fmt.Errorf("unexpected character %q at %d", r, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(input)}), nil
+}
+
+// closing finds the quote ending the string that starts at input[start],
+// skipping backslash escapes.
+func closing(input string, start int) int {
+	quote := input[start]
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) is(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokPunct && tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	if !p.is(text) {
+		return p.unexpected(p.peek())
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) unexpected(tok token) error {
+	if tok.kind == tokEOF {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+}
+
+func (p *parser) expression() (node, error) {
+	left, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("|") {
+		p.next()
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		left = subexpression{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("||") {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("&&") {
+		p.next()
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (node, error) {
+	if p.is("!") {
+		p.next()
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.comparison()
+}
+
+var comparators = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+func (p *parser) comparison() (node, error) {
+	left, err := p.chain()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == tokPunct && comparators[tok.text] {
+		p.next()
+		right, err := p.chain()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{tok.text, left, right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) chain() (node, error) {
+	primary, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	return p.postfix(primary, false)
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokIdent:
+		p.next()
+		if p.is("(") {
+			return p.function(tok)
+		}
+		return field{tok.text}, nil
+	case tokQuoted:
+		p.next()
+		return field{tok.value.(string)}, nil
+	case tokRaw, tokLiteral, tokNumber:
+		p.next()
+		return literal{tok.value}, nil
+	case tokEOF:
+		return nil, p.unexpected(tok)
+	}
+	switch tok.text {
+	case "@":
+		p.next()
+		return current{}, nil
+	case "*":
+		p.next()
+		right, err := p.projectionRight()
+		if err != nil {
+			return nil, err
+		}
+		return valuesProjection{current{}, right}, nil
+	case "[":
+		// A bracket specifier applies to the current node; anything else
+		// starts a multiselect list.
+		after := p.peekAt(1)
+		if after.kind == tokNumber || after.kind == tokPunct && (after.text == "]" || after.text == "?" || after.text == ":" || after.text == "*" && p.peekAt(2).text == "]") {
+			return current{}, nil
+		}
+		return p.multiselectList()
+	case "{":
+		return p.multiselectHash()
+	case "(":
+		p.next()
+		inner, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case "&":
+		p.next()
+		inner, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return expressionRef{inner}, nil
+	}
+	return nil, p.unexpected(tok)
+}
+
+// postfix parses the dot and bracket operators following a node. Inside a
+// projection it stops at a flatten, which applies to the projection's result.
+func (p *parser) postfix(left node, inProjection bool) (node, error) {
+	for {
+		switch {
+		case p.is("."):
+			p.next()
+			tok := p.peek()
+			switch {
+			case tok.kind == tokIdent:
+				p.next()
+				if p.is("(") {
+					return nil, p.unexpected(p.peek())
+				}
+				left = subexpression{left, field{tok.text}}
+			case tok.kind == tokQuoted:
+				p.next()
+				left = subexpression{left, field{tok.value.(string)}}
+			case p.is("*"):
+				p.next()
+				right, err := p.projectionRight()
+				if err != nil {
+					return nil, err
+				}
+				left = valuesProjection{left, right}
+			case p.is("["):
+				list, err := p.multiselectList()
+				if err != nil {
+					return nil, err
+				}
+				left = subexpression{left, list}
+			case p.is("{"):
+				hash, err := p.multiselectHash()
+				if err != nil {
+					return nil, err
+				}
+				left = subexpression{left, hash}
+			default:
+				return nil, p.unexpected(tok)
+			}
+		case p.is("["):
+			after := p.peekAt(1)
+			switch {
+			case after.kind == tokPunct && after.text == "]":
+				if inProjection {
+					return left, nil
+				}
+				p.pos += 2
+				right, err := p.projectionRight()
+				if err != nil {
+					return nil, err
+				}
+				left = projection{flatten{left}, right}
+			case after.kind == tokPunct && after.text == "*":
+				p.pos += 2
+				if err := p.expect("]"); err != nil {
+					return nil, err
+				}
+				right, err := p.projectionRight()
+				if err != nil {
+					return nil, err
+				}
+				left = projection{left, right}
+			case after.kind == tokPunct && after.text == "?":
+				p.pos += 2
+				condition, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect("]"); err != nil {
+					return nil, err
+				}
+				right, err := p.projectionRight()
+				if err != nil {
+					return nil, err
+				}
+				left = projection{filter{left, condition}, right}
+			default:
+				p.next()
+				bracket, isSlice, err := p.indexOrSlice()
+				if err != nil {
+					return nil, err
+				}
+				if !isSlice {
+					left = subexpression{left, bracket}
+					continue
+				}
+				right, err := p.projectionRight()
+				if err != nil {
+					return nil, err
+				}
+				left = projection{subexpression{left, bracket}, right}
+			}
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *parser) projectionRight() (node, error) {
+	return p.postfix(current{}, true)
+}
+
+// indexOrSlice parses what follows "[" up to and including "]".
+func (p *parser) indexOrSlice() (node, bool, error) {
+	var parts [3]*int
+	part := 0
+	for {
+		tok := p.next()
+		switch {
+		case tok.kind == tokNumber:
+			number := tok.value.(float64)
+			if number != math.Trunc(number) || parts[part] != nil {
+				return nil, false, p.unexpected(tok)
+			}
+			n := int(number)
+			parts[part] = &n
+		case tok.kind == tokPunct && tok.text == ":" && part < 2:
+			part++
+		case tok.kind == tokPunct && tok.text == "]":
+			if part == 0 {
+				if parts[0] == nil {
+					return nil, false, p.unexpected(tok)
+				}
+				return index{*parts[0]}, false, nil
+			}
+			if parts[2] != nil && *parts[2] == 0 {
+				return nil, false, fmt.Errorf("slice step cannot be 0")
+			}
+			return slice{parts[0], parts[1], parts[2]}, true, nil
+		default:
+			return nil, false, p.unexpected(tok)
+		}
+	}
+}
+
+func (p *parser) multiselectList() (node, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	var items []node
+	for {
+		item, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.is("]") {
+			p.next()
+			return multiList{items}, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) multiselectHash() (node, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var hash multiHash
+	for {
+		tok := p.next()
+		var key string
+		switch tok.kind {
+		case tokIdent:
+			key = tok.text
+		case tokQuoted:
+			key = tok.value.(string)
+		default:
+			return nil, p.unexpected(tok)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		hash.keys = append(hash.keys, key)
+		hash.values = append(hash.values, value)
+		if p.is("}") {
+			p.next()
+			return hash, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) function(name token) (node, error) {
+	spec, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s() at %d", name.text, name.pos)
+	}
+	p.next()
+	var args []node
+	for !p.is(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+	if len(args) < spec.min || spec.max >= 0 && len(args) > spec.max {
+		return nil, fmt.Errorf("%s() takes %s, got %d", name.text, spec.arity(), len(args))
+	}
+	return call{name.text, spec.fn, args}, nil
+}
+
+type node interface {
+	eval(value any) (any, error)
+}
+
+type current struct{}
+
+func (current) eval(value any) (any, error) {
+	return value, nil
+}
+
+type field struct {
+	name string
+}
+
+func (n field) eval(value any) (any, error) {
+	object, _ := value.(map[string]any)
+	return object[n.name], nil
+}
+
+type literal struct {
+	value any
+}
+
+func (n literal) eval(any) (any, error) {
+	return n.value, nil
+}
+
+// subexpression evaluates right against the result of left; it implements
+// both "a.b" and "a | b".
+type subexpression struct {
+	left, right node
+}
+
+func (n subexpression) eval(value any) (any, error) {
+	left, err := n.left.eval(value)
+	if err != nil {
+		return nil, err
+	}
+	return n.right.eval(left)
+}
+
+type index struct {
+	i int
+}
+
+func (n index) eval(value any) (any, error) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, nil
+	}
+	i := n.i
+	if i < 0 {
+		i += len(list)
+	}
+	if i < 0 || i >= len(list) {
+		return nil, nil
+	}
+	return list[i], nil
+}
+
+type slice struct {
+	start, stop, step *int
+}
+
+func (n slice) eval(value any) (any, error) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, nil
+	}
+	step := 1
+	if n.step != nil {
+		step = *n.step
+	}
+	bound := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += len(list)
+		}
+		if step > 0 {
+			return min(max(i, 0), len(list))
+		}
+		return min(max(i, -1), len(list)-1)
+	}
+	result := []any{}
+	if step > 0 {
+		for i := bound(n.start, 0); i < bound(n.stop, len(list)); i += step {
+			result = append(result, list[i])
+		}
+	} else {
+		for i := bound(n.start, len(list)-1); i > bound(n.stop, -1); i += step {
+			result = append(result, list[i])
+		}
+	}
+	return result, nil
+}
+
+type flatten struct {
+	left node
+}
+
+func (n flatten) eval(value any) (any, error) {
+	left, err := n.left.eval(value)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := left.([]any)
+	if !ok {
+		return nil, nil
+	}
+	result := []any{}
+	for _, item := range list {
+		if nested, ok := item.([]any); ok {
+			result = append(result, nested...)
+		} else {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+type filter struct {
+	left, condition node
+}
+
+func (n filter) eval(value any) (any, error) {
+	left, err := n.left.eval(value)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := left.([]any)
+	if !ok {
+		return nil, nil
+	}
+	result := []any{}
+	for _, item := range list {
+		keep, err := n.condition.eval(item)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(keep) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// projection applies right to every element of the list produced by left,
+// dropping null results.
+type projection struct {
+	left, right node
+}
+
+func (n projection) eval(value any) (any, error) {
+	left, err := n.left.eval(value)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := left.([]any)
+	if !ok {
+		return nil, nil
+	}
+	return project(list, n.right)
+}
+
+type valuesProjection struct {
+	left, right node
+}
+
+func (n valuesProjection) eval(value any) (any, error) {
+	left, err := n.left.eval(value)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := left.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	return project(objectValues(object), n.right)
+}
+
+func project(list []any, right node) (any, error) {
+	result := []any{}
+	for _, item := range list {
+		projected, err := right.eval(item)
+		if err != nil {
+			return nil, err
+		}
+		if projected != nil {
+			result = append(result, projected)
+		}
+	}
+	return result, nil
+}
+
+type multiList struct {
+	items []node
+}
+
+func (n multiList) eval(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	result := make([]any, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(value)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = v
+	}
+	return result, nil
+}
+
+type multiHash struct {
+	keys   []string
+	values []node
+}
+
+func (n multiHash) eval(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	result := make(map[string]any, len(n.keys))
+	for i, key := range n.keys {
+		v, err := n.values[i].eval(value)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = v
+	}
+	return result, nil
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) eval(value any) (any, error) {
+	left, err := n.left.eval(value)
+	if err != nil || truthy(left) {
+		return left, err
+	}
+	return n.right.eval(value)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) eval(value any) (any, error) {
+	left, err := n.left.eval(value)
+	if err != nil || !truthy(left) {
+		return left, err
+	}
+	return n.right.eval(value)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(value any) (any, error) {
+	operand, err := n.operand.eval(value)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(operand), nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) eval(value any) (any, error) {
+	left, err := n.left.eval(value)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(value)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	}
+	cmp, ok := compare(left, right)
+	if !ok {
+		return nil, nil
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// compare orders two numbers or two strings; other pairs are not ordered.
+func compare(a, b any) (int, bool) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1, true
+			case a > b:
+				return 1, true
+			}
+			return 0, true
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	}
+	return 0, false
+}
+
+// expressionRef is "&expr", passed unevaluated to functions such as sort_by.
+type expressionRef struct {
+	inner node
+}
+
+func (n expressionRef) eval(any) (any, error) {
+	return n, nil
+}
+
+type call struct {
+	name string
+	fn   func(args []any) (any, error)
+	args []node
+}
+
+func (n call) eval(value any) (any, error) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(value)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	result, err := n.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.name, err)
+	}
+	return result, nil
+}
+
+func truthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	return true
+}
+
+func objectValues(object map[string]any) []any {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		values[i] = object[key]
+	}
+	return values
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case expressionRef:
+		return "expref"
+	}
+	return fmt.Sprintf("%T", value)
+}
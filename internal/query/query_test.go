@@ -0,0 +1,94 @@
+package query
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const issuesJSON = `{
+  "total_count": 3,
+  "issues": [
+    {"id": 101, "subject": "Fix onboarding email", "status": {"id": 1, "name": "New"}, "priority": {"id": 3, "name": "High"}, "due_date": "2024-01-10", "tags": ["a", "b"]},
+    {"id": 102, "subject": "Add dark mode", "status": {"id": 2, "name": "In Progress"}, "priority": {"id": 2, "name": "Normal"}, "tags": [["c"], "d"]},
+    {"id": 104, "subject": "Crash on login", "status": {"id": 1, "name": "New"}, "priority": {"id": 4, "name": "Urgent"}, "due_date": "2024-01-08"}
+  ]
+}`
+
+func TestSearch(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(issuesJSON), &data); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	cases := []struct {
+		expression string
+		want       string
+	}{
+		{"total_count", `3`},
+		{"issues[0].subject", `"Fix onboarding email"`},
+		{"issues[-1].id", `104`},
+		{"issues[*].id", `[101,102,104]`},
+		{"issues[].status.name", `["New","In Progress","New"]`},
+		{"issues[*].due_date", `["2024-01-10","2024-01-08"]`},
+		{"issues[?status.name == 'New'].id", `[101,104]`},
+		{"issues[?priority.id > `2` && !due_date].id", `[]`},
+		{"issues[?priority.id >= 3 || id == `102`].id", `[101,102,104]`},
+		{"issues[?due_date < '2024-01-09'].subject", `["Crash on login"]`},
+		{"issues[?contains(subject, 'mode')] | [0].id", `102`},
+		{"issues[0:2].id", `[101,102]`},
+		{"issues[::-1].id", `[104,102,101]`},
+		{"issues[*].tags[]", `["a","b",["c"],"d"]`},
+		{"issues[0].{id: id, status: status.name}", `{"id":101,"status":"New"}`},
+		{"issues[*].[id, priority.name]", `[[101,"High"],[102,"Normal"],[104,"Urgent"]]`},
+		{"length(issues)", `3`},
+		{"sort_by(issues, &priority.id)[*].id", `[102,101,104]`},
+		{"max(issues[*].id)", `104`},
+		{"sum(issues[*].priority.id)", `9`},
+		{"join(', ', issues[*].status.name)", `"New, In Progress, New"`},
+		{"keys(issues[0].status)", `["id","name"]`},
+		{"issues[0].status.*", `[1,"New"]`},
+		{"not_null(issues[0].assigned_to, 'nobody')", `"nobody"`},
+		{"issues[5].id", `null`},
+		{`"total_count"`, `3`},
+	}
+	for _, tc := range cases {
+		q, err := Compile(tc.expression)
+		if err != nil {
+			t.Fatalf("%s: compile: %v", tc.expression, err)
+		}
+		result, err := q.Search(data)
+		if err != nil {
+			t.Fatalf("%s: search: %v", tc.expression, err)
+		}
+		got, _ := json.Marshal(result)
+		if string(got) != tc.want {
+			t.Fatalf("%s = %s, want %s", tc.expression, got, tc.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	compileErrors := map[string]string{
+		"issues[":          "unexpected end of expression",
+		"issues[?id ==":    "unexpected end of expression",
+		"issues..id":       `unexpected "." at 7`,
+		"nope(issues)":     "unknown function nope()",
+		"length(a, b)":     "length() takes 1 argument, got 2",
+		"issues[0:1:0]":    "slice step cannot be 0",
+		"'unterminated":    "unterminated '",
+		"issues # comment": `unexpected character '#'`,
+	}
+	for expression, want := range compileErrors {
+		if _, err := Compile(expression); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: err = %v, want %q", expression, err, want)
+		}
+	}
+
+	q, err := Compile("length(total_count)")
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if _, err := q.Search(map[string]any{"total_count": 3.0}); err == nil || err.Error() != "length(): expected string, array or object, got number" {
+		t.Fatalf("err = %v", err)
+	}
+}
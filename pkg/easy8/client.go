@@ -0,0 +1,159 @@
+package easy8
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+// Client bundles the services of one Easy8 instance. The service fields may
+// be replaced, e.g. with fakes in tests.
+type Client struct {
+	Issues     IssuesService
+	Users      UsersService
+	Projects   ProjectsService
+	Statuses   StatusesService
+	Trackers   TrackersService
+	Priorities PrioritiesService
+	Search     SearchService
+
+	api *api.Client
+}
+
+// Option configures a Client in New. Options are applied in order and can
+// only be made with the With functions of this package.
+type Option = api.Option
+
+func New(baseURL string, opts ...Option) (*Client, error) {
+	if strings.TrimSpace(baseURL) == "" {
+		return nil, fmt.Errorf("missing base URL")
+	}
+	c := &api.Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+		Retry:   api.DefaultRetryPolicy(),
+	}
+	for _, opt := range opts {
+		if err := opt.Apply(c); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{
+		Issues:     issues{c},
+		Users:      users{c},
+		Projects:   projects{c},
+		Statuses:   statuses{c},
+		Trackers:   trackers{c},
+		Priorities: priorities{c},
+		Search:     search{c},
+		api:        c,
+	}, nil
+}
+
+func (c *Client) BaseURL() string {
+	return c.api.BaseURL
+}
+
+// ActAs sends later requests on behalf of another user (login) through the
+// X-Redmine-Switch-User header. It needs an admin API key; an empty login
+// stops impersonating.
+func (c *Client) ActAs(login string) {
+	c.api.SwitchUser = login
+}
+
+// Use appends middleware next to the network. See WithMiddleware.
+func (c *Client) Use(middleware ...Middleware) {
+	c.api.Use(middleware...)
+}
+
+func WithAPIKey(key string) Option {
+	return api.OptionFunc(func(c *api.Client) error {
+		c.APIKey = key
+		return nil
+	})
+}
+
+// WithHTTPClient replaces the default http.Client (30s timeout).
+func WithHTTPClient(client *http.Client) Option {
+	return api.OptionFunc(func(c *api.Client) error {
+		if client == nil {
+			return fmt.Errorf("nil http client")
+		}
+		c.HTTP = client
+		return nil
+	})
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return api.OptionFunc(func(c *api.Client) error {
+		client := *c.HTTP
+		client.Timeout = timeout
+		c.HTTP = &client
+		return nil
+	})
+}
+
+func WithUserAgent(agent string) Option {
+	return api.OptionFunc(func(c *api.Client) error {
+		c.UserAgent = agent
+		return nil
+	})
+}
+
+func WithActAs(login string) Option {
+	return api.OptionFunc(func(c *api.Client) error {
+		c.SwitchUser = login
+		return nil
+	})
+}
+
+func WithRetry(policy RetryPolicy) Option {
+	return api.OptionFunc(func(c *api.Client) error {
+		if policy.Attempts < 1 {
+			return fmt.Errorf("retry attempts must be at least 1")
+		}
+		c.Retry = policy
+		return nil
+	})
+}
+
+// WithRateLimit paces requests to rps per second, shared by all services.
+func WithRateLimit(rps float64) Option {
+	return api.OptionFunc(func(c *api.Client) error {
+		if rps <= 0 {
+			return fmt.Errorf("rate limit must be positive")
+		}
+		c.Limiter = api.NewRateLimiter(rps, 0)
+		return nil
+	})
+}
+
+// WithConcurrency bounds the number of requests in flight.
+func WithConcurrency(n int) Option {
+	return api.OptionFunc(func(c *api.Client) error {
+		if n < 1 {
+			return fmt.Errorf("concurrency must be at least 1")
+		}
+		c.Inflight = api.NewSemaphore(n)
+		return nil
+	})
+}
+
+// WithMiddleware appends middleware after the defaults, next to the network,
+// so it sees every retry with its final headers.
+func WithMiddleware(middleware ...Middleware) Option {
+	return api.OptionFunc(func(c *api.Client) error {
+		c.Use(middleware...)
+		return nil
+	})
+}
+
+// WithIdempotencyKey marks ctx so that an issue creation made with it may be
+// retried; the key is sent as the Idempotency-Key header.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return api.WithIdempotencyKey(ctx, key)
+}
@@ -0,0 +1,78 @@
+// Package easy8 is a Go client for the Easy8 (Redmine) REST API:
+//
+//	client, err := easy8.New("https://demo.easysoftware.com", easy8.WithAPIKey(key))
+//	if err != nil {
+//		return err
+//	}
+//	issues, err := client.Issues.List(ctx, easy8.IssueListParams{AssigneeID: 51})
+//
+// Operations are grouped into per-resource services. Every service is an
+// interface, so code using the client can replace it with a fake in tests.
+package easy8
+
+import (
+	"io"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+// The request, response and error types are aliases of the types the CLI
+// itself uses, so values pass between the two without conversion. The
+// aliases, with their exported fields and methods, are the stable API of
+// this package; the package they point to is internal and may change.
+type (
+	Issue             = api.Issue
+	IssueInput        = api.IssueInput
+	IssueListParams   = api.IssueListParams
+	IssueListResponse = api.IssueListResponse
+	CustomField       = api.CustomField
+	Journal           = api.Journal
+	JournalDetail     = api.JournalDetail
+	Attachment        = api.Attachment
+	NamedRef          = api.NamedRef
+	User              = api.User
+	Project           = api.Project
+	Membership        = api.Membership
+	IssueStatus       = api.IssueStatus
+	Tracker           = api.Tracker
+	IssuePriority     = api.IssuePriority
+	SearchParams      = api.SearchParams
+	SearchResponse    = api.SearchResponse
+	SearchResult      = api.SearchResult
+
+	PageOptions = api.PageOptions
+	RetryPolicy = api.RetryPolicy
+
+	Middleware       = api.Middleware
+	RoundTripperFunc = api.RoundTripperFunc
+
+	// APIError is returned for every non-2xx response. Use errors.As to get
+	// at the status code and the server's validation messages.
+	APIError  = api.APIError
+	ErrorKind = api.ErrorKind
+)
+
+const (
+	ErrorKindAuth       = api.ErrorKindAuth
+	ErrorKindForbidden  = api.ErrorKindForbidden
+	ErrorKindNotFound   = api.ErrorKindNotFound
+	ErrorKindValidation = api.ErrorKindValidation
+	ErrorKindConflict   = api.ErrorKindConflict
+	ErrorKindRateLimit  = api.ErrorKindRateLimit
+	ErrorKindServer     = api.ErrorKindServer
+	ErrorKindOther      = api.ErrorKindOther
+)
+
+// ErrStopPaging can be returned from an Each callback to stop early without
+// an error.
+var ErrStopPaging = api.ErrStopPaging
+
+func DefaultRetryPolicy() RetryPolicy {
+	return api.DefaultRetryPolicy()
+}
+
+// Logging returns a middleware that logs every request to out; with trace
+// set it includes headers and bodies. Credentials are always redacted.
+func Logging(out io.Writer, trace bool) Middleware {
+	return api.Logging(out, trace)
+}
@@ -0,0 +1,147 @@
+package easy8
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Easy8Com/easy8-cli/pkg/easy8/easy8test"
+)
+
+func newMockClient(t *testing.T, opts ...Option) *Client {
+	t.Helper()
+	fixture := easy8test.DefaultFixture()
+	fixture.APIKey = "secret"
+	server := httptest.NewServer(easy8test.New(fixture))
+	t.Cleanup(server.Close)
+	client, err := New(server.URL+"/", append([]Option{WithAPIKey("secret"), WithHTTPClient(server.Client())}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return client
+}
+
+func TestServicesAgainstMockServer(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	client := newMockClient(t, WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			seen = append(seen, req.Method+" "+req.URL.Path+" "+req.Header.Get("X-Redmine-Switch-User"))
+			mu.Unlock()
+			return next.RoundTrip(req)
+		})
+	}))
+	ctx := context.Background()
+
+	subject, projectID := "Rotate keys", 2
+	issue, err := client.Issues.Create(ctx, IssueInput{Subject: &subject, ProjectID: &projectID})
+	if err != nil || issue.ID != 106 || issue.Project.Name != "Mobile App" {
+		t.Fatalf("create: %+v %v", issue, err)
+	}
+	statusID := 3
+	issue, err = client.Issues.Update(ctx, issue.ID, IssueInput{StatusID: &statusID})
+	if err != nil || issue.Status.Name != "Resolved" {
+		t.Fatalf("update: %+v %v", issue, err)
+	}
+	issue, err = client.Issues.Get(ctx, 106)
+	if err != nil || issue.Subject != subject {
+		t.Fatalf("get: %+v %v", issue, err)
+	}
+	if err := client.Issues.Delete(ctx, 106); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = client.Issues.Get(ctx, 106)
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.Kind() != ErrorKindNotFound {
+		t.Fatalf("get deleted: %v", err)
+	}
+
+	var ids []int
+	total, err := client.Issues.Each(ctx, IssueListParams{ProjectID: 2}, PageOptions{PageSize: 1}, func(issue Issue) error {
+		ids = append(ids, issue.ID)
+		if len(ids) == 2 {
+			return ErrStopPaging
+		}
+		return nil
+	})
+	if err != nil || total != 3 || len(ids) != 2 || ids[0] != 105 {
+		t.Fatalf("each: total=%d ids=%v err=%v", total, ids, err)
+	}
+
+	client.ActAs("alice")
+	users, err := client.Users.Search(ctx, "doe")
+	if err != nil || len(users) != 1 || users[0].Login != "alice" {
+		t.Fatalf("users: %+v %v", users, err)
+	}
+	projects, err := client.Projects.List(ctx)
+	if err != nil || len(projects) != 2 {
+		t.Fatalf("projects: %+v %v", projects, err)
+	}
+	trackers, err := client.Trackers.List(ctx)
+	if err != nil || len(trackers) != 3 {
+		t.Fatalf("trackers: %+v %v", trackers, err)
+	}
+	results, err := client.Search.Query(ctx, SearchParams{Query: "dark"})
+	if err != nil || results.TotalCount != 1 {
+		t.Fatalf("search: %+v %v", results, err)
+	}
+
+	if strings.HasSuffix(client.BaseURL(), "/") {
+		t.Fatalf("base URL not trimmed: %s", client.BaseURL())
+	}
+	if len(seen) != 11 || seen[0] != "POST /issues.json " || seen[len(seen)-1] != "GET /search.json alice" {
+		t.Fatalf("middleware saw %v", seen)
+	}
+}
+
+type fakeIssues struct {
+	IssuesService
+	created []IssueInput
+}
+
+func (f *fakeIssues) Create(ctx context.Context, input IssueInput) (Issue, error) {
+	f.created = append(f.created, input)
+	return Issue{ID: 1, Subject: *input.Subject}, nil
+}
+
+func TestServicesCanBeReplaced(t *testing.T) {
+	client, err := New("https://easy8.invalid")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	fake := &fakeIssues{}
+	client.Issues = fake
+
+	subject := "Offline"
+	issue, err := client.Issues.Create(context.Background(), IssueInput{Subject: &subject})
+	if err != nil || issue.ID != 1 || len(fake.created) != 1 {
+		t.Fatalf("issue=%+v err=%v", issue, err)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	if _, err := New(" "); err == nil {
+		t.Fatalf("expected error for an empty base URL")
+	}
+	for _, opt := range []Option{WithRetry(RetryPolicy{}), WithRateLimit(0), WithConcurrency(0), WithHTTPClient(nil)} {
+		if _, err := New("https://easy8.invalid", opt); err == nil {
+			t.Fatalf("expected option error")
+		}
+	}
+	_, err := New("https://easy8.invalid", WithRetry(DefaultRetryPolicy()), WithRateLimit(5), WithConcurrency(2), WithTimeout(0), WithUserAgent("billing-sync"), WithActAs("alice"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	client := newMockClient(t, WithAPIKey("wrong"))
+	_, err = client.Statuses.List(context.Background())
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.Kind() != ErrorKindAuth {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
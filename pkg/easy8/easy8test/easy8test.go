@@ -0,0 +1,608 @@
+// Package easy8test is an in-memory fake of the Easy8 REST API for local
+// integration runs and tests of code using package easy8:
+//
+//	server := httptest.NewServer(easy8test.New(easy8test.DefaultFixture()))
+//	defer server.Close()
+//	client, err := easy8.New(server.URL, easy8.WithAPIKey("any"))
+//
+// The fixture holds the data types of package easy8 (easy8.Issue, ...).
+package easy8test
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+//go:embed default_fixture.json
+var defaultFixture []byte
+
+// Fixture is the initial state of a Server. With APIKey set, requests must
+// send it (or a bearer token with the same value).
+type Fixture struct {
+	APIKey     string              `json:"api_key,omitempty"`
+	Users      []api.User          `json:"users"`
+	Projects   []api.Project       `json:"projects"`
+	Statuses   []api.IssueStatus   `json:"issue_statuses"`
+	Trackers   []api.Tracker       `json:"trackers"`
+	Priorities []api.IssuePriority `json:"issue_priorities"`
+	Issues     []api.Issue         `json:"issues"`
+
+	Memberships []api.Membership `json:"memberships"`
+}
+
+func DefaultFixture() Fixture {
+	var fixture Fixture
+	if err := json.Unmarshal(defaultFixture, &fixture); err != nil {
+		panic(err)
+	}
+	return fixture
+}
+
+func LoadFixture(path string) (Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return Fixture{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+type Server struct {
+	mu      sync.Mutex
+	data    Fixture
+	nextID  int
+	now     func() time.Time
+	handler *http.ServeMux
+}
+
+func New(fixture Fixture) *Server {
+	s := &Server{data: fixture, now: time.Now}
+	s.data.Issues = append([]api.Issue(nil), fixture.Issues...)
+	for i := range s.data.Issues {
+		s.fillRefs(&s.data.Issues[i])
+		if s.data.Issues[i].ID > s.nextID {
+			s.nextID = s.data.Issues[i].ID
+		}
+	}
+
+	s.handler = http.NewServeMux()
+	s.handler.HandleFunc("/issues.json", s.handleIssues)
+	s.handler.HandleFunc("/issues/", s.handleIssue)
+	s.handler.HandleFunc("/users.json", s.handleUsers)
+	s.handler.HandleFunc("/projects.json", s.handleProjects)
+	s.handler.HandleFunc("/projects/", s.handleMemberships)
+	s.handler.HandleFunc("/issue_statuses.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.IssueStatusListResponse{IssueStatuses: s.data.Statuses})
+	})
+	s.handler.HandleFunc("/trackers.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.TrackerListResponse{Trackers: s.data.Trackers})
+	})
+	s.handler.HandleFunc("/enumerations/issue_priorities.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.IssuePriorityListResponse{IssuePriorities: s.data.Priorities})
+	})
+	s.handler.HandleFunc("/search.json", s.handleSearch)
+	return s
+}
+
+// Issues returns a snapshot of the current issues.
+func (s *Server) Issues() []api.Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]api.Issue(nil), s.data.Issues...)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeErrors(w, http.StatusUnauthorized, "Invalid API key")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.data.APIKey == "" {
+		return true
+	}
+	if r.Header.Get("X-Redmine-API-Key") == s.data.APIKey {
+		return true
+	}
+	return r.Header.Get("Authorization") == "Bearer "+s.data.APIKey
+}
+
+func (s *Server) handleIssues(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		var matches []api.Issue
+		for _, issue := range s.data.Issues {
+			if matchesIssue(issue, query) {
+				matches = append(matches, issue)
+			}
+		}
+		sortIssues(matches, query.Get("sort"))
+		offset, limit := paging(query)
+		page := pageOf(matches, offset, limit)
+		for i := range page {
+			page[i] = withIncludes(page[i], query.Get("include"))
+		}
+		writeJSON(w, http.StatusOK, api.IssueListResponse{
+			Issues:     page,
+			TotalCount: len(matches),
+			Offset:     offset,
+			Limit:      limit,
+		})
+	case http.MethodPost:
+		var request api.IssueRequest
+		if !decode(w, r, &request) {
+			return
+		}
+		if errs := s.validate(request.Issue, true); len(errs) > 0 {
+			writeErrors(w, http.StatusUnprocessableEntity, errs...)
+			return
+		}
+		s.nextID++
+		now := s.now().UTC().Format(time.RFC3339)
+		issue := api.Issue{ID: s.nextID, CreatedOn: now}
+		s.apply(&issue, request.Issue)
+		if issue.Tracker == nil && len(s.data.Trackers) > 0 {
+			issue.Tracker = &api.NamedRef{ID: s.data.Trackers[0].ID}
+		}
+		if issue.Status == nil && len(s.data.Statuses) > 0 {
+			issue.Status = &api.NamedRef{ID: s.data.Statuses[0].ID}
+		}
+		if issue.Priority == nil && len(s.data.Priorities) > 0 {
+			issue.Priority = &api.NamedRef{ID: s.data.Priorities[0].ID}
+		}
+		s.fillRefs(&issue)
+		s.data.Issues = append(s.data.Issues, issue)
+		writeJSON(w, http.StatusCreated, api.IssueResponse{Issue: issue})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleIssue(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/issues/"), ".json"))
+	if err != nil || !strings.HasSuffix(r.URL.Path, ".json") {
+		writeErrors(w, http.StatusNotFound)
+		return
+	}
+	index := -1
+	for i, issue := range s.data.Issues {
+		if issue.ID == id {
+			index = i
+		}
+	}
+	if index < 0 {
+		writeErrors(w, http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, api.IssueResponse{Issue: withIncludes(s.data.Issues[index], r.URL.Query().Get("include"))})
+	case http.MethodPut:
+		var request api.IssueRequest
+		if !decode(w, r, &request) {
+			return
+		}
+		if errs := s.validate(request.Issue, false); len(errs) > 0 {
+			writeErrors(w, http.StatusUnprocessableEntity, errs...)
+			return
+		}
+		issue := s.data.Issues[index]
+		s.apply(&issue, request.Issue)
+		issue.UpdatedOn = s.now().UTC().Format(time.RFC3339)
+		s.fillRefs(&issue)
+		s.data.Issues[index] = issue
+		writeJSON(w, http.StatusOK, api.IssueResponse{Issue: withIncludes(issue, "")})
+	case http.MethodDelete:
+		s.data.Issues = append(s.data.Issues[:index], s.data.Issues[index+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := strings.ToLower(strings.TrimSpace(query.Get("name")))
+	var matches []api.User
+	for _, user := range s.data.Users {
+		full := strings.ToLower(user.Firstname + " " + user.Lastname)
+		if name == "" || strings.Contains(strings.ToLower(user.Login), name) || strings.Contains(full, name) {
+			matches = append(matches, user)
+		}
+	}
+	offset, limit := paging(query)
+	writeJSON(w, http.StatusOK, api.UserListResponse{Users: pageOf(matches, offset, limit), TotalCount: len(matches), Offset: offset, Limit: limit})
+}
+
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	offset, limit := paging(r.URL.Query())
+	writeJSON(w, http.StatusOK, api.ProjectListResponse{Projects: pageOf(s.data.Projects, offset, limit), TotalCount: len(s.data.Projects), Offset: offset, Limit: limit})
+}
+
+// handleMemberships serves /projects/{id}/memberships.json.
+func (s *Server) handleMemberships(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/projects/"), "/memberships.json")
+	id, err := strconv.Atoi(rest)
+	if !ok || err != nil || s.projectName(id) == "" {
+		writeErrors(w, http.StatusNotFound)
+		return
+	}
+	var matches []api.Membership
+	for _, membership := range s.data.Memberships {
+		if membership.Project == nil || membership.Project.ID != id {
+			continue
+		}
+		membership.Project = &api.NamedRef{ID: id, Name: s.projectName(id)}
+		if membership.User != nil && membership.User.Name == "" {
+			membership.User = &api.NamedRef{ID: membership.User.ID, Name: s.userName(membership.User.ID)}
+		}
+		matches = append(matches, membership)
+	}
+	offset, limit := paging(r.URL.Query())
+	writeJSON(w, http.StatusOK, api.MembershipListResponse{Memberships: pageOf(matches, offset, limit), TotalCount: len(matches), Offset: offset, Limit: limit})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	needle := strings.ToLower(strings.TrimSpace(query.Get("q")))
+	var results []api.SearchResult
+	for _, issue := range s.data.Issues {
+		if needle != "" && !strings.Contains(strings.ToLower(issue.Subject+" "+issue.Description), needle) {
+			continue
+		}
+		results = append(results, api.SearchResult{
+			ID:          issue.ID,
+			Type:        "issue",
+			Title:       fmt.Sprintf("%s #%d (%s): %s", refName(issue.Tracker), issue.ID, refName(issue.Status), issue.Subject),
+			URL:         fmt.Sprintf("/issues/%d", issue.ID),
+			Description: issue.Description,
+			Datetime:    issue.UpdatedOn,
+		})
+	}
+	if query.Get("issues") != "1" {
+		for _, project := range s.data.Projects {
+			if needle != "" && !strings.Contains(strings.ToLower(project.Name), needle) {
+				continue
+			}
+			results = append(results, api.SearchResult{ID: project.ID, Type: "project", Title: "Project: " + project.Name, URL: fmt.Sprintf("/projects/%d", project.ID)})
+		}
+	}
+	offset, limit := paging(query)
+	writeJSON(w, http.StatusOK, api.SearchResponse{Results: pageOf(results, offset, limit), TotalCount: len(results), Offset: offset, Limit: limit})
+}
+
+func (s *Server) validate(input api.IssueInput, create bool) []string {
+	var errs []string
+	if create && (input.Subject == nil || strings.TrimSpace(*input.Subject) == "") || input.Subject != nil && strings.TrimSpace(*input.Subject) == "" {
+		errs = append(errs, "Subject cannot be blank")
+	}
+	if create && input.ProjectID == nil {
+		errs = append(errs, "Project cannot be blank")
+	}
+	if input.ProjectID != nil && s.projectName(*input.ProjectID) == "" {
+		errs = append(errs, "Project is not included in the list")
+	}
+	if input.StatusID != nil && s.statusName(*input.StatusID) == "" {
+		errs = append(errs, "Status is not included in the list")
+	}
+	if input.TrackerID != nil && s.trackerName(*input.TrackerID) == "" {
+		errs = append(errs, "Tracker is not included in the list")
+	}
+	if input.PriorityID != nil && s.priorityName(*input.PriorityID) == "" {
+		errs = append(errs, "Priority is not included in the list")
+	}
+	if input.AssignedToID != nil && *input.AssignedToID != 0 && s.userName(*input.AssignedToID) == "" {
+		errs = append(errs, "Assignee is invalid")
+	}
+	if input.DoneRatio != nil && (*input.DoneRatio < 0 || *input.DoneRatio > 100) {
+		errs = append(errs, "% Done is not included in the list")
+	}
+	return errs
+}
+
+func (s *Server) apply(issue *api.Issue, input api.IssueInput) {
+	if input.Subject != nil {
+		issue.Subject = *input.Subject
+	}
+	if input.Description != nil {
+		issue.Description = *input.Description
+	}
+	if input.StartDate != nil {
+		issue.StartDate = *input.StartDate
+	}
+	if input.DueDate != nil {
+		issue.DueDate = *input.DueDate
+	}
+	if input.DoneRatio != nil {
+		issue.DoneRatio = *input.DoneRatio
+	}
+	if input.ProjectID != nil {
+		issue.Project = &api.NamedRef{ID: *input.ProjectID}
+	}
+	if input.TrackerID != nil {
+		issue.Tracker = &api.NamedRef{ID: *input.TrackerID}
+	}
+	if input.StatusID != nil {
+		issue.Status = &api.NamedRef{ID: *input.StatusID}
+	}
+	if input.PriorityID != nil {
+		issue.Priority = &api.NamedRef{ID: *input.PriorityID}
+	}
+	if input.AuthorID != nil {
+		issue.Author = &api.NamedRef{ID: *input.AuthorID}
+	}
+	if input.AssignedToID != nil {
+		issue.AssignedTo = nil
+		if *input.AssignedToID != 0 {
+			issue.AssignedTo = &api.NamedRef{ID: *input.AssignedToID}
+		}
+	}
+	if issue.UpdatedOn == "" {
+		issue.UpdatedOn = issue.CreatedOn
+	}
+}
+
+// fillRefs sets the names of an issue's references from the lookup tables, so
+// fixtures only need to give IDs.
+func (s *Server) fillRefs(issue *api.Issue) {
+	fill := func(ref *api.NamedRef, name func(int) string) {
+		if ref != nil && ref.Name == "" {
+			ref.Name = name(ref.ID)
+		}
+	}
+	fill(issue.Project, s.projectName)
+	fill(issue.Tracker, s.trackerName)
+	fill(issue.Status, s.statusName)
+	fill(issue.Priority, s.priorityName)
+	fill(issue.Author, s.userName)
+	fill(issue.AssignedTo, s.userName)
+	for _, journal := range issue.Journals {
+		fill(journal.User, s.userName)
+	}
+	for _, attachment := range issue.Attachments {
+		fill(attachment.Author, s.userName)
+	}
+}
+
+// withIncludes drops the journals and attachments of an issue unless they
+// are requested with include, like the real API.
+func withIncludes(issue api.Issue, include string) api.Issue {
+	requested := make(map[string]bool)
+	for _, name := range strings.Split(include, ",") {
+		requested[strings.TrimSpace(name)] = true
+	}
+	if !requested["journals"] {
+		issue.Journals = nil
+	}
+	if !requested["attachments"] {
+		issue.Attachments = nil
+	}
+	return issue
+}
+
+func (s *Server) projectName(id int) string {
+	for _, item := range s.data.Projects {
+		if item.ID == id {
+			return item.Name
+		}
+	}
+	return ""
+}
+
+func (s *Server) trackerName(id int) string {
+	for _, item := range s.data.Trackers {
+		if item.ID == id {
+			return item.Name
+		}
+	}
+	return ""
+}
+
+func (s *Server) statusName(id int) string {
+	for _, item := range s.data.Statuses {
+		if item.ID == id {
+			return item.Name
+		}
+	}
+	return ""
+}
+
+func (s *Server) priorityName(id int) string {
+	for _, item := range s.data.Priorities {
+		if item.ID == id {
+			return item.Name
+		}
+	}
+	return ""
+}
+
+func (s *Server) userName(id int) string {
+	for _, item := range s.data.Users {
+		if item.ID == id {
+			return strings.TrimSpace(item.Firstname + " " + item.Lastname)
+		}
+	}
+	return ""
+}
+
+func matchesIssue(issue api.Issue, query map[string][]string) bool {
+	get := func(key string) string {
+		if values := query[key]; len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+	refs := map[string]*api.NamedRef{
+		"project_id":     issue.Project,
+		"tracker_id":     issue.Tracker,
+		"status_id":      issue.Status,
+		"priority_id":    issue.Priority,
+		"assigned_to_id": issue.AssignedTo,
+		"author_id":      issue.Author,
+	}
+	for key, ref := range refs {
+		value := get(key)
+		if value == "" || value == "*" {
+			continue
+		}
+		if ref == nil || !containsID(value, ref.ID) {
+			return false
+		}
+	}
+	if due := get("due_date"); due != "" && issue.DueDate != due {
+		return false
+	}
+	if subject := strings.TrimPrefix(get("subject"), "~"); subject != "" && !strings.Contains(strings.ToLower(issue.Subject), strings.ToLower(subject)) {
+		return false
+	}
+	if text := strings.ToLower(get("easy_query_q")); text != "" && !strings.Contains(strings.ToLower(issue.Subject+" "+issue.Description), text) {
+		return false
+	}
+	return true
+}
+
+func containsID(value string, id int) bool {
+	for _, part := range strings.Split(value, "|") {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && parsed == id {
+			return true
+		}
+	}
+	return false
+}
+
+func sortIssues(issues []api.Issue, expression string) {
+	if strings.TrimSpace(expression) == "" {
+		sort.SliceStable(issues, func(i, j int) bool { return issues[i].ID > issues[j].ID })
+		return
+	}
+	keys := strings.Split(expression, ",")
+	sort.SliceStable(issues, func(i, j int) bool {
+		for _, key := range keys {
+			field, direction, _ := strings.Cut(strings.TrimSpace(key), ":")
+			left, right := sortValue(issues[i], field), sortValue(issues[j], field)
+			if left == right {
+				continue
+			}
+			if direction == "desc" {
+				return left > right
+			}
+			return left < right
+		}
+		return false
+	})
+}
+
+func sortValue(issue api.Issue, field string) string {
+	switch field {
+	case "id":
+		return fmt.Sprintf("%010d", issue.ID)
+	case "subject":
+		return strings.ToLower(issue.Subject)
+	case "due_date":
+		return issue.DueDate
+	case "created_on":
+		return issue.CreatedOn
+	case "updated_on":
+		return issue.UpdatedOn
+	case "priority":
+		return refID(issue.Priority)
+	case "status":
+		return refID(issue.Status)
+	case "tracker":
+		return refID(issue.Tracker)
+	case "project":
+		return refID(issue.Project)
+	}
+	return ""
+}
+
+func refID(ref *api.NamedRef) string {
+	if ref == nil {
+		return ""
+	}
+	return fmt.Sprintf("%010d", ref.ID)
+}
+
+func refName(ref *api.NamedRef) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.Name
+}
+
+// paging reads offset and limit like Redmine: limit defaults to 25 and is
+// capped at 100.
+func paging(query map[string][]string) (int, int) {
+	offset, limit := 0, 25
+	if values := query["offset"]; len(values) > 0 {
+		if parsed, err := strconv.Atoi(values[0]); err == nil && parsed > 0 {
+			offset = parsed
+		}
+	}
+	if values := query["limit"]; len(values) > 0 {
+		if parsed, err := strconv.Atoi(values[0]); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return offset, limit
+}
+
+func pageOf[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+func decode(w http.ResponseWriter, r *http.Request, out any) bool {
+	data, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(data, out)
+	}
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeErrors(w http.ResponseWriter, status int, messages ...string) {
+	if len(messages) == 0 {
+		w.WriteHeader(status)
+		return
+	}
+	writeJSON(w, status, map[string][]string{"errors": messages})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,156 @@
+package easy8test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+func newTestClient(t *testing.T, fixture Fixture) (*api.Client, *Server) {
+	t.Helper()
+	mock := New(fixture)
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+	return &api.Client{BaseURL: server.URL, APIKey: "key", HTTP: server.Client()}, mock
+}
+
+func TestIssueCRUD(t *testing.T) {
+	client, mock := newTestClient(t, DefaultFixture())
+	ctx := context.Background()
+
+	subject := "Write release notes"
+	projectID, assigneeID := 1, 12
+	created, err := client.CreateIssue(ctx, api.IssueInput{Subject: &subject, ProjectID: &projectID, AssignedToID: &assigneeID})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	issue := created.Issue
+	if issue.ID != 106 || issue.Project.Name != "Website" || issue.AssignedTo.Name != "Bob Smith" || issue.Status.Name != "New" || issue.Tracker.Name != "Bug" {
+		t.Fatalf("unexpected issue: %+v", issue)
+	}
+
+	statusID, ratio := 2, 50
+	updated, err := client.UpdateIssue(ctx, issue.ID, api.IssueInput{StatusID: &statusID, DoneRatio: &ratio})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Issue.Status.Name != "In Progress" || updated.Issue.DoneRatio != 50 || updated.Issue.Subject != subject {
+		t.Fatalf("unexpected update: %+v", updated.Issue)
+	}
+
+	blank := " "
+	_, err = client.CreateIssue(ctx, api.IssueInput{Subject: &blank})
+	var apiErr api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity || len(apiErr.Errors) != 2 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, client.BaseURL+"/issues/106.json", nil)
+	resp, err := client.HTTP.Do(req)
+	if err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: %v %v", resp, err)
+	}
+	resp.Body.Close()
+	resp, err = client.HTTP.Get(client.BaseURL + "/issues/106.json")
+	if err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("get deleted: %v %v", resp, err)
+	}
+	resp.Body.Close()
+	if len(mock.Issues()) != 5 {
+		t.Fatalf("issues = %d", len(mock.Issues()))
+	}
+}
+
+func TestIssueFiltersAndPaging(t *testing.T) {
+	client, _ := newTestClient(t, DefaultFixture())
+	ctx := context.Background()
+
+	resp, err := client.ListIssues(ctx, api.IssueListParams{AssigneeID: 11, Sort: "priority:desc"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if resp.TotalCount != 2 || resp.Issues[0].ID != 104 || resp.Issues[1].ID != 101 {
+		t.Fatalf("unexpected issues: %+v", resp)
+	}
+
+	resp, err = client.ListIssues(ctx, api.IssueListParams{Query: "password reset"})
+	if err != nil || len(resp.Issues) != 1 || resp.Issues[0].ID != 104 {
+		t.Fatalf("query: %+v %v", resp, err)
+	}
+
+	resp, err = client.ListIssues(ctx, api.IssueListParams{Subject: "~dark", ProjectID: 2})
+	if err != nil || len(resp.Issues) != 1 || resp.Issues[0].ID != 102 {
+		t.Fatalf("subject: %+v %v", resp, err)
+	}
+
+	all, err := api.CollectAll(ctx, client.IssuePages(api.IssueListParams{}), api.PageOptions{PageSize: 2})
+	if err != nil || len(all) != 5 || all[0].ID != 105 {
+		t.Fatalf("paging: %+v %v", all, err)
+	}
+}
+
+func TestLookupsAndSearch(t *testing.T) {
+	client, _ := newTestClient(t, DefaultFixture())
+	ctx := context.Background()
+
+	users, err := client.SearchUsers(ctx, "novák")
+	if err != nil || len(users) != 1 || users[0].Login != "pnovak" {
+		t.Fatalf("users: %+v %v", users, err)
+	}
+	statuses, err := client.ListIssueStatuses(ctx)
+	if err != nil || len(statuses) != 4 {
+		t.Fatalf("statuses: %+v %v", statuses, err)
+	}
+	priorities, err := client.ListIssuePriorities(ctx)
+	if err != nil || len(priorities) != 4 {
+		t.Fatalf("priorities: %+v %v", priorities, err)
+	}
+
+	memberships, err := client.ListProjectMemberships(ctx, 2)
+	if err != nil || len(memberships) != 2 || memberships[1].User.Name != "Petr Novák" || memberships[1].Project.Name != "Mobile App" || len(memberships[1].Roles) != 2 {
+		t.Fatalf("memberships: %+v %v", memberships, err)
+	}
+	if _, err := client.ListProjectMemberships(ctx, 9); !errors.As(err, new(api.APIError)) {
+		t.Fatalf("unknown project: %v", err)
+	}
+
+	results, err := client.Search(ctx, api.SearchParams{Query: "login", IssuesOnly: true})
+	if err != nil || results.TotalCount != 1 || results.Results[0].Title != "Bug #104 (New): Crash on login with SSO" {
+		t.Fatalf("search: %+v %v", results, err)
+	}
+}
+
+func TestAPIKeyAndFixtureFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	data := `{"api_key": "secret", "projects": [{"id": 7, "name": "Ops"}], "issues": [{"id": 9, "subject": "Rotate keys", "project": {"id": 7}}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	client, _ := newTestClient(t, fixture)
+
+	_, err = client.ListIssues(context.Background(), api.IssueListParams{})
+	var apiErr api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client.APIKey = "secret"
+	resp, err := client.ListIssues(context.Background(), api.IssueListParams{})
+	if err != nil || len(resp.Issues) != 1 || resp.Issues[0].Project.Name != "Ops" {
+		t.Fatalf("list: %+v %v", resp, err)
+	}
+
+	if _, err := LoadFixture(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatalf("expected error for a missing fixture")
+	}
+}
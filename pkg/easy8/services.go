@@ -0,0 +1,120 @@
+package easy8
+
+import (
+	"context"
+
+	"github.com/Easy8Com/easy8-cli/internal/api"
+)
+
+type IssuesService interface {
+	// List returns one page of issues; params.Limit and params.Offset select it.
+	List(ctx context.Context, params IssueListParams) (IssueListResponse, error)
+	// Each calls yield for every matching issue across all pages and returns
+	// the total reported by the server. params.Limit and params.Offset are
+	// ignored; use opts instead.
+	Each(ctx context.Context, params IssueListParams, opts PageOptions, yield func(Issue) error) (int, error)
+	Get(ctx context.Context, id int, include ...string) (Issue, error)
+	Create(ctx context.Context, input IssueInput) (Issue, error)
+	Update(ctx context.Context, id int, input IssueInput) (Issue, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type UsersService interface {
+	List(ctx context.Context) ([]User, error)
+	// Search uses the server-side name filter (login, first or last name).
+	Search(ctx context.Context, name string) ([]User, error)
+}
+
+type ProjectsService interface {
+	List(ctx context.Context) ([]Project, error)
+	Memberships(ctx context.Context, projectID int) ([]Membership, error)
+}
+
+type StatusesService interface {
+	List(ctx context.Context) ([]IssueStatus, error)
+}
+
+type TrackersService interface {
+	List(ctx context.Context) ([]Tracker, error)
+}
+
+type PrioritiesService interface {
+	List(ctx context.Context) ([]IssuePriority, error)
+}
+
+type SearchService interface {
+	Query(ctx context.Context, params SearchParams) (SearchResponse, error)
+}
+
+type issues struct{ c *api.Client }
+
+func (s issues) List(ctx context.Context, params IssueListParams) (IssueListResponse, error) {
+	return s.c.ListIssues(ctx, params)
+}
+
+func (s issues) Each(ctx context.Context, params IssueListParams, opts PageOptions, yield func(Issue) error) (int, error) {
+	return api.Paginate(ctx, s.c.IssuePages(params), opts, yield)
+}
+
+func (s issues) Get(ctx context.Context, id int, include ...string) (Issue, error) {
+	resp, err := s.c.GetIssue(ctx, id, include...)
+	return resp.Issue, err
+}
+
+func (s issues) Create(ctx context.Context, input IssueInput) (Issue, error) {
+	resp, err := s.c.CreateIssue(ctx, input)
+	return resp.Issue, err
+}
+
+func (s issues) Update(ctx context.Context, id int, input IssueInput) (Issue, error) {
+	resp, err := s.c.UpdateIssue(ctx, id, input)
+	return resp.Issue, err
+}
+
+func (s issues) Delete(ctx context.Context, id int) error {
+	return s.c.DeleteIssue(ctx, id)
+}
+
+type users struct{ c *api.Client }
+
+func (s users) List(ctx context.Context) ([]User, error) {
+	return s.c.ListUsers(ctx)
+}
+
+func (s users) Search(ctx context.Context, name string) ([]User, error) {
+	return s.c.SearchUsers(ctx, name)
+}
+
+type projects struct{ c *api.Client }
+
+func (s projects) List(ctx context.Context) ([]Project, error) {
+	return s.c.ListProjects(ctx)
+}
+
+func (s projects) Memberships(ctx context.Context, projectID int) ([]Membership, error) {
+	return s.c.ListProjectMemberships(ctx, projectID)
+}
+
+type statuses struct{ c *api.Client }
+
+func (s statuses) List(ctx context.Context) ([]IssueStatus, error) {
+	return s.c.ListIssueStatuses(ctx)
+}
+
+type trackers struct{ c *api.Client }
+
+func (s trackers) List(ctx context.Context) ([]Tracker, error) {
+	return s.c.ListTrackers(ctx)
+}
+
+type priorities struct{ c *api.Client }
+
+func (s priorities) List(ctx context.Context) ([]IssuePriority, error) {
+	return s.c.ListIssuePriorities(ctx)
+}
+
+type search struct{ c *api.Client }
+
+func (s search) Query(ctx context.Context, params SearchParams) (SearchResponse, error) {
+	return s.c.Search(ctx, params)
+}